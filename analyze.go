@@ -0,0 +1,100 @@
+package binocular
+
+import (
+	"strings"
+
+	"github.com/kljensen/snowball"
+)
+
+// TokenFilter is the step of the analysis which dropped a Token.
+type TokenFilter int
+
+const (
+	// NoTokenFilter marks a Token which is stored in the Index.
+	NoTokenFilter TokenFilter = iota
+	// SpecialCharsFilter drops words which are empty after removing all characters but letters and digits.
+	SpecialCharsFilter
+	// ShortWordsFilter drops words with two or less characters unless WithShortWords is enabled.
+	ShortWordsFilter
+	// StopWordsFilter drops stop words unless WithStopWords is enabled.
+	StopWordsFilter
+)
+
+func (filter TokenFilter) String() string {
+	switch filter {
+	case NoTokenFilter:
+		return "none"
+	case SpecialCharsFilter:
+		return "special_chars"
+	case ShortWordsFilter:
+		return "short_words"
+	case StopWordsFilter:
+		return "stop_words"
+	default:
+		return "unknown"
+	}
+}
+
+// Token is a word of an analyzed text.
+type Token struct {
+	// Term is the word as it is stored in the Index after removing special characters, lowercasing and stemming.
+	Term string
+	// Text is the word as it appears in the analyzed text.
+	Text string
+	// Position is the number of the word in the analyzed text, starting at 0.
+	Position int
+	// Start and End are the byte offsets of Text in the analyzed text.
+	Start int
+	End   int
+	// DroppedBy is the filter which removed the Token or NoTokenFilter if the Term is stored.
+	DroppedBy TokenFilter
+}
+
+// Dropped reports whether the Token is not stored in the Index.
+func (token Token) Dropped() bool {
+	return token.DroppedBy != NoTokenFilter
+}
+
+// Analyze returns every word of the given text with the term it is stored as when the text is added to the Index
+// or the filter which dropped it. A keyword Index returns the whole text as a single Token.
+func (index *Index) Analyze(text string) []Token {
+	tokens := make([]Token, 0)
+	index.tokenize(text, func(token Token) {
+		tokens = append(tokens, token)
+	})
+	return tokens
+}
+
+// tokenize calls fn with every word of the sentence in the order they appear.
+func (index *Index) tokenize(sentence string, fn func(token Token)) {
+	if index.keyword {
+		if sentence != "" {
+			fn(Token{Term: sentence, Text: sentence, End: len(sentence)})
+		}
+		return
+	}
+	start := 0
+	for position, word := range strings.Split(sentence, " ") {
+		token := Token{Text: word, Position: position, Start: start, End: start + len(word)}
+		start = token.End + 1
+		token.Term = stripSpecialChars([]byte(word))
+		if index.stemming {
+			stemmed, err := snowball.Stem(token.Term, "english", index.keepStopWords)
+			if err == nil {
+				token.Term = stemmed
+				fn(token)
+				continue
+			}
+		}
+		token.Term = strings.ToLower(token.Term)
+		switch {
+		case !index.keepShortWords && token.Term == "" && word != "":
+			token.DroppedBy = SpecialCharsFilter
+		case !index.keepShortWords && len(token.Term) <= 2:
+			token.DroppedBy = ShortWordsFilter
+		case !index.keepStopWords && isStopWord(token.Term):
+			token.DroppedBy = StopWordsFilter
+		}
+		fn(token)
+	}
+}
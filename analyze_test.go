@@ -0,0 +1,90 @@
+package binocular
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndex_Analyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []IndexOption
+		text     string
+		expected []Token
+	}{
+		{
+			"basic",
+			nil,
+			"We have -- a Problem!",
+			[]Token{
+				{Term: "we", Text: "We", Position: 0, Start: 0, End: 2, DroppedBy: ShortWordsFilter},
+				{Term: "have", Text: "have", Position: 1, Start: 3, End: 7, DroppedBy: StopWordsFilter},
+				{Term: "", Text: "--", Position: 2, Start: 8, End: 10, DroppedBy: SpecialCharsFilter},
+				{Term: "a", Text: "a", Position: 3, Start: 11, End: 12, DroppedBy: ShortWordsFilter},
+				{Term: "problem", Text: "Problem!", Position: 4, Start: 13, End: 21},
+			},
+		},
+		{
+			"keep short and stop words",
+			[]IndexOption{WithShortWords(), WithStopWords()},
+			"We have",
+			[]Token{
+				{Term: "we", Text: "We", Position: 0, Start: 0, End: 2},
+				{Term: "have", Text: "have", Position: 1, Start: 3, End: 7},
+			},
+		},
+		{
+			"stemming",
+			[]IndexOption{WithStemming()},
+			"we had problems",
+			[]Token{
+				{Term: "we", Text: "we", Position: 0, Start: 0, End: 2},
+				{Term: "had", Text: "had", Position: 1, Start: 3, End: 6},
+				{Term: "problem", Text: "problems", Position: 2, Start: 7, End: 15},
+			},
+		},
+		{
+			"keyword",
+			[]IndexOption{WithKeyword()},
+			"We had problems",
+			[]Token{{Term: "We had problems", Text: "We had problems", End: 15}},
+		},
+		{"empty keyword", []IndexOption{WithKeyword()}, "", []Token{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := NewIndex(tt.options...)
+			tokens := index.Analyze(tt.text)
+			if !reflect.DeepEqual(tokens, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, tokens)
+			}
+			terms := make([]string, 0)
+			for _, token := range tokens {
+				if tt.text[token.Start:token.End] != token.Text {
+					t.Errorf("wrong offsets for %q", token.Text)
+				}
+				if !token.Dropped() {
+					terms = append(terms, token.Term)
+				}
+			}
+			if stored := index.analyze(tt.text); len(stored) != len(terms) || (len(terms) > 0 && !reflect.DeepEqual(stored, terms)) {
+				t.Errorf("tokens %v should match the stored terms %v", terms, stored)
+			}
+		})
+	}
+}
+
+func TestTokenFilter_String(t *testing.T) {
+	filters := map[TokenFilter]string{
+		NoTokenFilter:      "none",
+		SpecialCharsFilter: "special_chars",
+		ShortWordsFilter:   "short_words",
+		StopWordsFilter:    "stop_words",
+		TokenFilter(-1):    "unknown",
+	}
+	for filter, expected := range filters {
+		if filter.String() != expected {
+			t.Errorf("expected %s, got %s", expected, filter)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package binocular
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// WithBatchWorkers sets the number of goroutines analyzing the documents of a Batch.
+// It defaults to GOMAXPROCS.
+func WithBatchWorkers(workers int) Option {
+	return func(binocular *Binocular) {
+		if workers > 0 {
+			binocular.workers = workers
+		}
+	}
+}
+
+// Batch collects documents which are added to a Binocular instance at once.
+// The documents are analyzed in parallel and become visible to searchers together
+// when the Batch is committed. A Batch is not safe for concurrent use.
+type Batch struct {
+	binocular *Binocular
+	ids       []string
+	data      []interface{}
+}
+
+// NewBatch creates a new empty Batch for the Binocular instance.
+func (binocular *Binocular) NewBatch() *Batch {
+	return &Batch{
+		binocular: binocular,
+	}
+}
+
+// Add will create a new id for your data and adds it to the Batch.
+func (batch *Batch) Add(data interface{}) string {
+	id := uuid.New().String()
+	batch.AddWithID(id, data)
+	return id
+}
+
+// AddWithID adds the data with the given id to the Batch.
+func (batch *Batch) AddWithID(id string, data interface{}) {
+	batch.ids = append(batch.ids, id)
+	batch.data = append(batch.data, data)
+}
+
+// Len returns the number of documents in the Batch.
+func (batch *Batch) Len() int {
+	return len(batch.ids)
+}
+
+// Reset removes all documents from the Batch.
+func (batch *Batch) Reset() {
+	batch.ids = nil
+	batch.data = nil
+}
+
+// Commit adds all documents of the Batch to the Binocular instance and resets the Batch.
+// Existing ids are replaced like with AddWithID. If a document cannot be added,
+// e.g. because of ErrUnknownField or ErrRefExists, none of the documents are added.
+func (batch *Batch) Commit() error {
+	return batch.CommitContext(context.Background())
+}
+
+// CommitContext is like Commit but stops analyzing the documents and returns ctx.Err()
+// if the context is done before the documents are added. The Batch is not reset in that case.
+func (batch *Batch) CommitContext(ctx context.Context) error {
+	binocular := batch.binocular
+	analyzed, err := batch.analyze(ctx)
+	if err != nil {
+		return err
+	}
+
+	binocular.mut.Lock()
+	defer binocular.unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if binocular.uniqueIDs {
+		seen := make(map[string]struct{}, len(batch.ids))
+		for _, id := range batch.ids {
+			if _, ok := binocular.docs[id]; ok {
+				return ErrRefExists
+			}
+			if _, ok := seen[id]; ok {
+				return ErrRefExists
+			}
+			seen[id] = struct{}{}
+		}
+	}
+	// the last document wins if an id is added multiple times
+	last := make(map[string]int, len(batch.ids))
+	keep := make(map[string]struct{}, len(batch.ids))
+	for i, id := range batch.ids {
+		last[id] = i
+		keep[id] = struct{}{}
+	}
+	var size int64
+	for id, i := range last {
+		size += analyzed[i].doc.size
+		if old, ok := binocular.docs[id]; ok {
+			size -= old.size
+		}
+	}
+	if err := binocular.reserve(size, keep); err != nil {
+		return err
+	}
+	postings := make(map[string]map[string][]string)
+	types := make(map[string]FieldType)
+	added := 0
+	for i, id := range batch.ids {
+		if last[id] != i {
+			continue
+		}
+		old, ok := binocular.docs[id]
+		if ok {
+			for name := range old.recordLocator {
+				binocular.indices[name].Remove(id)
+			}
+		}
+		binocular.docs[id] = analyzed[i].doc
+		binocular.track(id, old, analyzed[i].doc)
+		if ok {
+			binocular.emit(DocumentUpdated, id, "")
+		} else {
+			added++
+			binocular.emit(DocumentAdded, id, "")
+		}
+		for name, typ := range analyzed[i].types {
+			types[name] = typ
+		}
+		for name, terms := range analyzed[i].terms {
+			if postings[name] == nil {
+				postings[name] = make(map[string][]string)
+			}
+			for _, term := range terms {
+				postings[name][term] = append(postings[name][term], id)
+			}
+		}
+	}
+	for name, p := range postings {
+		binocular.getOrCreateIndex(name, types[name]).addPostings(p)
+	}
+	// only the newest documents of the batch which fit within the capacity are kept
+	newest := make(map[string]struct{}, len(last))
+	for i := len(batch.ids) - 1; i >= 0 && len(newest) < binocular.capacity; i-- {
+		newest[batch.ids[i]] = struct{}{}
+	}
+	binocular.enforceCapacity(newest)
+	if binocular.metrics != nil && added > 0 {
+		binocular.metrics.DocumentsAdded(added)
+	}
+	batch.Reset()
+	return nil
+}
+
+// analyzedDocument is a document of a Batch with the analyzed terms and FieldType for each Index.
+type analyzedDocument struct {
+	doc   *document
+	terms map[string][]string
+	types map[string]FieldType
+}
+
+// analyzes the documents of the Batch in parallel and returns the first error encountered.
+func (batch *Batch) analyze(ctx context.Context) ([]analyzedDocument, error) {
+	binocular := batch.binocular
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
+
+	analyzed := make([]analyzedDocument, len(batch.data))
+	errs := make([]error, len(batch.data))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < binocular.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				analyzed[i], errs[i] = binocular.analyzeDocument(batch.ids[i], batch.data[i])
+			}
+		}()
+	}
+	var cancelled error
+	for i := range batch.data {
+		if cancelled = ctx.Err(); cancelled != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if cancelled != nil {
+		return nil, cancelled
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return analyzed, nil
+}
+
+// creates a new document from the data and analyzes its values with the analyzer of their Index.
+// Values of indices which do not exist yet are analyzed like a new Index would do.
+func (binocular *Binocular) analyzeDocument(id string, data interface{}) (analyzedDocument, error) {
+	doc, types, err := binocular.newDocument(data, nil)
+	if err != nil {
+		return analyzedDocument{}, err
+	}
+	doc.size = estimateDocument(id, doc)
+	result := analyzedDocument{
+		doc:   doc,
+		terms: make(map[string][]string, len(doc.recordLocator)),
+		types: types,
+	}
+	for name, values := range doc.recordLocator {
+		index, ok := binocular.indices[name]
+		if !ok {
+			index = defaultAnalyzers[types[name]]
+		}
+		for _, value := range values {
+			result.terms[name] = append(result.terms[name], index.analyze(value)...)
+		}
+	}
+	return result, nil
+}
+
+// defaultAnalyzers analyze values for indices which are created on demand.
+var defaultAnalyzers = map[FieldType]*Index{
+	TextField:    NewIndex(TextField.options(nil)...),
+	KeywordField: NewIndex(KeywordField.options(nil)...),
+	NumericField: NewIndex(NumericField.options(nil)...),
+}
@@ -0,0 +1,203 @@
+package binocular
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/tjarratt/babble"
+)
+
+func TestBatch_Commit(t *testing.T) {
+	b := New(WithIndex("title", WithStemming()), WithBatchWorkers(4))
+	_ = b.AddWithID("existing", "Lorem ipsum")
+	batch := b.NewBatch()
+	id := batch.Add("Always look on the bright side of life")
+	batch.AddWithID("existing", "Houston we have a problem")
+	batch.AddWithID("struct", struct {
+		Title string `binocular:"title"`
+	}{
+		"There are too many cats!",
+	})
+	if batch.Len() != 3 {
+		t.Errorf("expected 3 documents, got %d", batch.Len())
+	}
+	err := batch.Commit()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if batch.Len() != 0 {
+		t.Error("batch should be reset")
+	}
+	if b.indices[DefaultIndex].Postings("bright")[0] != id {
+		t.Error("wrong id")
+	}
+	if len(b.indices[DefaultIndex].Postings("lorem")) != 0 {
+		t.Error("stale term should not match")
+	}
+	if b.indices[DefaultIndex].Postings("houston")[0] != "existing" {
+		t.Error("wrong id")
+	}
+	if b.indices["title"].Postings("cat")[0] != "struct" {
+		t.Error("value should be analyzed by the index")
+	}
+	data, err := b.Get("existing")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if data != "Houston we have a problem" {
+		t.Error("wrong data")
+	}
+}
+
+func TestBatch_Commit_DuplicateID(t *testing.T) {
+	b := New()
+	batch := b.NewBatch()
+	batch.AddWithID("123", "Lorem ipsum")
+	batch.AddWithID("123", "dolor sit amet")
+	err := batch.Commit()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(b.indices[DefaultIndex].Postings("lorem")) != 0 {
+		t.Error("last document should win")
+	}
+	if len(b.indices[DefaultIndex].Postings("dolor")) != 1 {
+		t.Error("wrong result len")
+	}
+}
+
+func TestBatch_Commit_Errors(t *testing.T) {
+	schema, err := NewSchema(Field{Name: "title"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b := New(WithStrictSchema(schema), WithUniqueIDs())
+	_ = b.AddWithID("existing", "Lorem ipsum")
+
+	batch := b.NewBatch()
+	batch.AddWithID("123", "dolor sit amet")
+	batch.AddWithID("456", struct {
+		Color string `binocular:"color"`
+	}{
+		"brown",
+	})
+	err = batch.Commit()
+	if !errors.Is(err, ErrUnknownField) {
+		t.Errorf("wrong error: %v", err)
+	}
+	if b.docs["123"] != nil {
+		t.Error("no document should be added")
+	}
+
+	batch = b.NewBatch()
+	batch.AddWithID("123", "dolor sit amet")
+	batch.AddWithID("existing", "consetetur sadipscing elitr")
+	err = batch.Commit()
+	if err != ErrRefExists {
+		t.Errorf("wrong error: %v", err)
+	}
+	if b.docs["123"] != nil {
+		t.Error("no document should be added")
+	}
+}
+
+func TestBatch_CommitContext_Cancelled(t *testing.T) {
+	b := New()
+	batch := b.NewBatch()
+	batch.AddWithID("123", "Lorem ipsum")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := batch.CommitContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("wrong error: %v", err)
+	}
+	if b.docs["123"] != nil {
+		t.Error("no document should be added")
+	}
+	if batch.Len() != 1 {
+		t.Error("batch should not be reset")
+	}
+}
+
+func TestBatch_Commit_Concurrent(t *testing.T) {
+	b := New()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			batch := b.NewBatch()
+			for i := 0; i < 100; i++ {
+				batch.AddWithID(strconv.Itoa(w*100+i), "Lorem ipsum")
+			}
+			if err := batch.Commit(); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}(w)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := b.Search("lorem", DefaultIndex)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return
+			}
+			if len(result.Refs())%100 != 0 {
+				t.Errorf("batch should be visible atomically, got %d refs", len(result.Refs()))
+			}
+		}()
+	}
+	wg.Wait()
+	result, err := b.Search("lorem", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 400 {
+		t.Errorf("expected 400 refs, got %d", len(result.Refs()))
+	}
+}
+
+func BenchmarkBatch_Commit(b *testing.B) {
+	testdata := []struct {
+		name      string
+		options   []IndexOption
+		batchSize int
+		wordCount int
+	}{
+		{
+			"basic",
+			[]IndexOption{},
+			1e+4,
+			10,
+		},
+		{
+			"stemming",
+			[]IndexOption{WithStemming()},
+			1e+4,
+			10,
+		},
+	}
+	for _, td := range testdata {
+		b.Run(td.name, func(b *testing.B) {
+			babbler := babble.NewBabbler()
+			babbler.Separator = " "
+			babbler.Count = td.wordCount
+			sentences := make([]string, td.batchSize)
+			for i := range sentences {
+				sentences[i] = babbler.Babble()
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bin := New(WithDefaultIndex(DefaultIndex, td.options...))
+				batch := bin.NewBatch()
+				for j, sentence := range sentences {
+					batch.AddWithID(strconv.Itoa(j), sentence)
+				}
+				_ = batch.Commit()
+			}
+		})
+	}
+}
@@ -1,11 +1,18 @@
 package binocular
 
 import (
+	"container/list"
+	"context"
+	"encoding/json"
 	"errors"
 	"reflect"
+	"runtime"
+	"sync"
+	"time"
 
-	"github.com/fatih/structtag"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // DefaultIndex is the default Index when adding data to a Binocular.
@@ -17,17 +24,68 @@ var ErrIndexNotFound = errors.New("index not found")
 // ErrRefNotFound indicates that the given reference does not exist.
 var ErrRefNotFound = errors.New("ref not found")
 
+// ErrRefExists indicates that the given reference already exists.
+var ErrRefExists = errors.New("ref already exists")
+
+// ErrDefaultIndex indicates that the operation is not allowed for the default Index.
+var ErrDefaultIndex = errors.New("not allowed for the default index")
+
 // Binocular holds you data and can use multiple Indices for searching it.
 // DefaultIndex is the currently configured default Index for the given Binocular instance.
+// It is safe for concurrent use.
 type Binocular struct {
+	mut          sync.RWMutex
 	docs         map[string]*document
 	indices      map[string]*Index
+	refs         *refTable
+	mappings     map[string]string
+	schema       *Schema
+	strict       bool
+	uniqueIDs    bool
+	workers      int
+	memoryLimit  int64
+	limitPolicy  LimitPolicy
+	usage        int64
+	order        *list.List
+	filters      *filterCache
+	configured   map[string][]IndexOption
+	tenantMut    sync.Mutex
+	tenants      map[string]*Binocular
+	tenant       string
+	events       *eventBus
+	pending      []Event
+	expiries     expiryQueue
+	expiryEvery  time.Duration
+	expirerDone  chan struct{}
+	closed       chan struct{}
+	closeOnce    sync.Once
+	capacity     int
+	eviction     EvictionPolicy
+	onEvict      func(id string, data interface{})
+	evicted      []evicted
+	accessMut    sync.Mutex
+	accesses     accessQueue
+	ticks        uint64
+	metrics      Metrics
+	tracer       trace.Tracer
 	DefaultIndex string
 }
 
+// document holds the data and the values added to each Index for it.
+// Size is the estimated memory usage, elem its position in the insertion order,
+// expires the time after which it is removed or zero and access its use for WithCapacity.
 type document struct {
 	Data          interface{}
-	recordLocator map[string]struct{}
+	recordLocator map[string][]string
+	size          int64
+	elem          *list.Element
+	expires       time.Time
+	access        *accessEntry
+}
+
+// expired reports whether the document has an expiry at or before the given time.
+func (doc *document) expired(now time.Time) bool {
+	return !doc.expires.IsZero() && !doc.expires.After(now)
 }
 
 // Option can alter the behavior if a Binocular instance.
@@ -38,14 +96,29 @@ func New(options ...Option) *Binocular {
 	binocular := &Binocular{
 		docs:         map[string]*document{},
 		indices:      map[string]*Index{},
+		refs:         newRefTable(),
+		mappings:     map[string]string{},
+		workers:      runtime.GOMAXPROCS(0),
+		order:        list.New(),
+		filters:      newFilterCache(),
+		tenants:      map[string]*Binocular{},
+		events:       &eventBus{},
+		closed:       make(chan struct{}),
+		tracer:       noop.NewTracerProvider().Tracer(tracerName),
 		DefaultIndex: DefaultIndex,
 	}
 	for _, opt := range options {
 		opt(binocular)
 	}
 	if _, ok := binocular.indices[binocular.DefaultIndex]; !ok {
-		binocular.indices[DefaultIndex] = NewIndex()
+		binocular.indices[DefaultIndex] = binocular.newIndex()
 	}
+	binocular.configured = make(map[string][]IndexOption, len(binocular.indices))
+	for name, index := range binocular.indices {
+		binocular.configured[name] = index.options
+		binocular.instrument(name, index)
+	}
+	binocular.startExpirer()
 	return binocular
 }
 
@@ -53,92 +126,361 @@ func New(options ...Option) *Binocular {
 func WithDefaultIndex(name string, options ...IndexOption) Option {
 	return func(binocular *Binocular) {
 		binocular.DefaultIndex = name
-		binocular.indices[name] = NewIndex(options...)
+		binocular.indices[name] = binocular.newIndex(options...)
 	}
 }
 
 // WithIndex creates a new Index with the given name and IndexOptions.
 func WithIndex(name string, options ...IndexOption) Option {
 	return func(binocular *Binocular) {
-		binocular.indices[name] = NewIndex(options...)
+		binocular.indices[name] = binocular.newIndex(options...)
+	}
+}
+
+// WithUniqueIDs makes AddWithID return ErrRefExists instead of replacing the data of an existing id.
+func WithUniqueIDs() Option {
+	return func(binocular *Binocular) {
+		binocular.uniqueIDs = true
 	}
 }
 
 // Add will create a new id for your data and adds it to the Binocular instance.
-func (binocular *Binocular) Add(data interface{}) string {
+func (binocular *Binocular) Add(data interface{}) (string, error) {
 	id := uuid.New().String()
-	binocular.AddWithID(id, data)
-	return id
+	return id, binocular.AddWithID(id, data)
 }
 
 // AddWithID adds the data with the given id to the Binocular instance.
-func (binocular *Binocular) AddWithID(id string, data interface{}) {
-	doc := document{
+// Strings are added to the default Index, structs are added by their `binocular` tags
+// and map[string]interface{} or json.RawMessage documents are added by their field mappings.
+// If the id already exists its data is replaced like with Upsert unless WithUniqueIDs is used,
+// then ErrRefExists is returned instead.
+// ErrUnknownField is returned in strict mode if a struct has a tagged field which is not part of the Schema.
+// The decoding error is returned if a json.RawMessage is not valid JSON.
+func (binocular *Binocular) AddWithID(id string, data interface{}) error {
+	return binocular.put(id, data, nil, putAdd, 0)
+}
+
+// AddWithIDContext is like AddWithID but returns ctx.Err() if the context is done before the data has been added.
+func (binocular *Binocular) AddWithIDContext(ctx context.Context, id string, data interface{}) (err error) {
+	_, span := binocular.startSpan(ctx, "binocular.AddWithID", attrRef.String(id))
+	defer func() { endSpan(span, err) }()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return binocular.AddWithID(id, data)
+}
+
+// Update replaces the data of the given id and only re-indexes the indices whose values have changed.
+// ErrRefNotFound is returned if the given id does not exist.
+func (binocular *Binocular) Update(id string, data interface{}) error {
+	return binocular.put(id, data, nil, putUpdate, 0)
+}
+
+// Upsert updates the data of the given id if it exists or adds it otherwise.
+func (binocular *Binocular) Upsert(id string, data interface{}) error {
+	return binocular.put(id, data, nil, putUpsert, 0)
+}
+
+// putMode determines how put handles existing and missing ids.
+type putMode int
+
+const (
+	putAdd putMode = iota
+	putUpdate
+	putUpsert
+)
+
+// puts the data with the given id according to the mode and uses the given plan for structs or the cached one if it is nil.
+// A positive ttl overrides the expiry of a tagged struct field.
+func (binocular *Binocular) put(id string, data interface{}, plan *structPlan, mode putMode, ttl time.Duration) error {
+	doc, types, err := binocular.newDocument(data, plan)
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		doc.expires = time.Now().Add(ttl)
+	}
+	doc.size = estimateDocument(id, doc)
+	binocular.mut.Lock()
+	defer binocular.unlock()
+	old, exists := binocular.docs[id]
+	if mode == putUpdate && !exists {
+		return ErrRefNotFound
+	}
+	if mode == putAdd && exists && binocular.uniqueIDs {
+		return ErrRefExists
+	}
+	size := doc.size
+	if exists {
+		size -= old.size
+	}
+	if err := binocular.reserve(size, map[string]struct{}{id: {}}); err != nil {
+		return err
+	}
+	binocular.docs[id] = doc
+	binocular.reindex(id, old, doc, types)
+	binocular.track(id, old, doc)
+	binocular.enforceCapacity(map[string]struct{}{id: {}})
+	if exists {
+		binocular.emit(DocumentUpdated, id, "")
+	} else {
+		if binocular.metrics != nil {
+			binocular.metrics.DocumentsAdded(1)
+		}
+		binocular.emit(DocumentAdded, id, "")
+	}
+	return nil
+}
+
+// creates a new document and collects the values for each Index from the data.
+// The FieldTypes of struct fields are returned by the name of their Index.
+func (binocular *Binocular) newDocument(data interface{}, plan *structPlan) (*document, map[string]FieldType, error) {
+	doc := &document{
 		Data:          data,
-		recordLocator: make(map[string]struct{}),
+		recordLocator: make(map[string][]string),
 	}
-	binocular.docs[id] = &doc
 
 	switch v := doc.Data.(type) {
 	case string:
-		binocular.indices[binocular.DefaultIndex].Add(v, id)
-		doc.recordLocator[binocular.DefaultIndex] = struct{}{}
+		doc.recordLocator[binocular.DefaultIndex] = []string{v}
+	case map[string]interface{}:
+		binocular.parseValue(doc, "", v)
+	case json.RawMessage:
+		if err := binocular.parseJSON(doc, v); err != nil {
+			return nil, nil, err
+		}
 	default:
-		t := reflect.TypeOf(doc.Data)
-		if t.Kind() == reflect.Struct {
-			binocular.parseStruct(id, &doc, t)
+		val := reflect.ValueOf(doc.Data)
+		if val.Kind() == reflect.Pointer && !val.IsNil() {
+			val = val.Elem()
+		}
+		if val.Kind() != reflect.Struct {
+			return doc, nil, nil
+		}
+		if plan == nil {
+			plan = cachedStructPlan(val.Type())
+		}
+		fields, err := binocular.parseStruct(val, plan)
+		if err != nil {
+			return nil, nil, err
+		}
+		if plan.expires != nil {
+			doc.expires, _ = val.FieldByIndex(plan.expires).Interface().(time.Time)
+		}
+		types := make(map[string]FieldType, len(fields))
+		for _, f := range fields {
+			doc.recordLocator[f.index] = append(doc.recordLocator[f.index], f.value)
+			types[f.index] = f.typ
+		}
+		return doc, types, nil
+	}
+	return doc, nil, nil
+}
+
+// adds the values of the document to their indices and removes the id from indices
+// the old document was added to, indices with unchanged values are skipped.
+// Missing indices are created for the given FieldTypes.
+func (binocular *Binocular) reindex(id string, old *document, doc *document, types map[string]FieldType) {
+	for name, values := range doc.recordLocator {
+		index := binocular.getOrCreateIndex(name, types[name])
+		if old != nil {
+			if oldValues, ok := old.recordLocator[name]; ok {
+				if equalValues(oldValues, values) {
+					continue
+				}
+				index.Remove(id)
+			}
+		}
+		for _, value := range values {
+			index.Add(value, id)
+		}
+	}
+	if old == nil {
+		return
+	}
+	for name := range old.recordLocator {
+		if _, ok := doc.recordLocator[name]; !ok {
+			binocular.indices[name].Remove(id)
 		}
 	}
 }
 
 // Get will retrieve the data at the given id.
-// ErrRefNotFound is returned if the data does not exist.
+// ErrRefNotFound is returned if the data does not exist or has expired.
 func (binocular *Binocular) Get(id string) (interface{}, error) {
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
 	doc, ok := binocular.docs[id]
-	if !ok {
+	if !ok || doc.expired(time.Now()) {
 		return nil, ErrRefNotFound
 	}
+	binocular.touch(doc)
 	return doc.Data, nil
 }
 
-// Search will search the given index with the given word and returns a SearchResult.
-// ErrIndexNotFound is returned if the given index does not exist.
-func (binocular *Binocular) Search(word string, index string) (*SearchResult, error) {
-	i, ok := binocular.indices[index]
+// RecordLocator returns the values the document with the given id has been added with to each Index.
+// ErrRefNotFound is returned if the document does not exist.
+func (binocular *Binocular) RecordLocator(id string) (map[string][]string, error) {
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
+	doc, ok := binocular.docs[id]
 	if !ok {
-		return nil, ErrIndexNotFound
+		return nil, ErrRefNotFound
 	}
-	result := binocular.newSearchResult()
-	result.refs = i.Search(word, 0)
-	return result, nil
+	locator := make(map[string][]string, len(doc.recordLocator))
+	for name, values := range doc.recordLocator {
+		locator[name] = append([]string(nil), values...)
+	}
+	return locator, nil
+}
+
+// Search will search the given index with the given word and returns a SearchResult.
+// The result is restricted to the references matching all given Filters.
+// ErrIndexNotFound is returned if the given index or the index of a Filter does not exist.
+func (binocular *Binocular) Search(word string, index string, filters ...Filter) (*SearchResult, error) {
+	return binocular.query(context.Background(), Query{All: []string{word}}, index, filters)
+}
+
+// SearchContext is like Search but returns ctx.Err() if the context is done before the search has finished.
+func (binocular *Binocular) SearchContext(ctx context.Context, word string, index string, filters ...Filter) (*SearchResult, error) {
+	return binocular.tracedQuery(ctx, "binocular.Search", Query{All: []string{word}}, index, filters)
 }
 
 // FuzzySearch will use the distance to search the given index with the given word and returns a SearchResult.
-// ErrIndexNotFound is returned if the given index does not exist.
-func (binocular *Binocular) FuzzySearch(word string, index string, distance int) (*SearchResult, error) {
+// The result is restricted to the references matching all given Filters.
+// ErrIndexNotFound is returned if the given index or the index of a Filter does not exist.
+func (binocular *Binocular) FuzzySearch(word string, index string, distance int, filters ...Filter) (*SearchResult, error) {
+	return binocular.query(context.Background(), Query{All: []string{word}, Distance: distance}, index, filters)
+}
+
+// FuzzySearchContext is like FuzzySearch but returns ctx.Err() if the context is done before the search has finished.
+func (binocular *Binocular) FuzzySearchContext(ctx context.Context, word string, index string, distance int, filters ...Filter) (*SearchResult, error) {
+	return binocular.tracedQuery(ctx, "binocular.FuzzySearch", Query{All: []string{word}, Distance: distance}, index, filters)
+}
+
+// Query will search the given index with the given Query and returns a SearchResult.
+// The result is restricted to the references matching all given Filters.
+// ErrIndexNotFound is returned if the given index or the index of a Filter does not exist.
+func (binocular *Binocular) Query(query Query, index string, filters ...Filter) (*SearchResult, error) {
+	return binocular.query(context.Background(), query, index, filters)
+}
+
+// QueryContext is like Query but returns ctx.Err() if the context is done before the search has finished.
+func (binocular *Binocular) QueryContext(ctx context.Context, query Query, index string, filters ...Filter) (*SearchResult, error) {
+	return binocular.tracedQuery(ctx, "binocular.Query", query, index, filters)
+}
+
+// tracedQuery searches the given index within a span of the given name.
+func (binocular *Binocular) tracedQuery(ctx context.Context, name string, query Query, index string, filters []Filter) (result *SearchResult, err error) {
+	ctx, span := binocular.startSpan(ctx, name,
+		attrIndex.String(index),
+		attrQueryLength.Int(queryLength(query)),
+		attrDistance.Int(query.Distance),
+		attrFilters.Int(len(filters)),
+	)
+	defer func() { endSpan(span, err) }()
+	return binocular.query(ctx, query, index, filters)
+}
+
+// query searches the given index and adds the analyzer and the number of hits to the span of the context if any.
+func (binocular *Binocular) query(ctx context.Context, query Query, index string, filters []Filter) (*SearchResult, error) {
+	span := trace.SpanFromContext(ctx)
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
 	i, ok := binocular.indices[index]
 	if !ok {
 		return nil, ErrIndexNotFound
 	}
+	span.SetAttributes(attrAnalyzer.String(i.analyzer()))
+	filter, err := binocular.filter(filters)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := i.search(ctx, query, filter)
+	if err != nil {
+		return nil, err
+	}
+	hits = binocular.unexpired(hits)
+	span.SetAttributes(attrHits.Int(len(hits)))
 	result := binocular.newSearchResult()
-	result.refs = i.Search(word, distance)
+	result.hits = hits
+	result.refs = make([]string, len(hits))
+	for j, hit := range hits {
+		result.refs[j] = hit.Ref
+	}
 	return result, nil
 }
 
 // Remove deletes the given id from all indices and the internal data map.
 // ErrRefNotFound is returned if the given id does not exist.
 func (binocular *Binocular) Remove(id string) error {
-	doc, ok := binocular.docs[id]
-	if !ok {
+	binocular.mut.Lock()
+	defer binocular.unlock()
+	if _, ok := binocular.docs[id]; !ok {
 		return ErrRefNotFound
 	}
-	for i := range doc.recordLocator {
-		binocular.indices[i].Remove(id)
+	binocular.remove(id)
+	return nil
+}
+
+// RemoveContext is like Remove but returns ctx.Err() if the context is done before the id has been removed.
+func (binocular *Binocular) RemoveContext(ctx context.Context, id string) (err error) {
+	_, span := binocular.startSpan(ctx, "binocular.Remove", attrRef.String(id))
+	defer func() { endSpan(span, err) }()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return binocular.Remove(id)
+}
+
+// DropIndex deletes the Index with the given name and its values from the record locators of all documents.
+// The documents themselves are kept. The Index is created again if data is added for it later on.
+// ErrIndexNotFound is returned if the given index does not exist and ErrDefaultIndex if it is the default Index.
+func (binocular *Binocular) DropIndex(name string) error {
+	binocular.mut.Lock()
+	defer binocular.unlock()
+	index, ok := binocular.indices[name]
+	if !ok {
+		return ErrIndexNotFound
 	}
-	delete(binocular.docs, id)
+	if name == binocular.DefaultIndex {
+		return ErrDefaultIndex
+	}
+	index.Drop()
+	delete(binocular.indices, name)
+	for id, doc := range binocular.docs {
+		if _, ok := doc.recordLocator[name]; !ok {
+			continue
+		}
+		delete(doc.recordLocator, name)
+		binocular.usage -= doc.size
+		doc.size = estimateDocument(id, doc)
+		binocular.usage += doc.size
+	}
+	binocular.emit(IndexDropped, "", name)
 	return nil
 }
 
+// remove deletes the existing id from all indices and the internal data map and releases its document number.
+// Every Index is checked, not only the ones of the record locator, so no bitmap keeps the number once it is reused.
+// The caller must hold the write lock.
+func (binocular *Binocular) remove(id string) {
+	doc := binocular.docs[id]
+	for _, index := range binocular.indices {
+		index.Remove(id)
+	}
+	delete(binocular.docs, id)
+	binocular.refs.release(id)
+	binocular.usage -= doc.size
+	binocular.order.Remove(doc.elem)
+	binocular.untrackAccess(doc)
+	if binocular.metrics != nil {
+		binocular.metrics.DocumentsRemoved(1)
+	}
+	binocular.emit(DocumentRemoved, id, "")
+}
+
 func (binocular *Binocular) newSearchResult() *SearchResult {
 	return &SearchResult{
 		binocular: binocular,
@@ -149,6 +491,7 @@ func (binocular *Binocular) newSearchResult() *SearchResult {
 type SearchResult struct {
 	binocular *Binocular
 	refs      []string
+	hits      []Hit
 }
 
 // Refs returns the list of references found for your search.
@@ -159,39 +502,80 @@ func (searchResult *SearchResult) Refs() []string {
 // Collect will use the found references and returns the data associated with it.
 // ErrRefNotFound is returned if a reference does not exist.
 func (searchResult *SearchResult) Collect() ([]interface{}, error) {
+	searchResult.binocular.mut.RLock()
+	defer searchResult.binocular.mut.RUnlock()
 	data := make([]interface{}, len(searchResult.refs))
 	for i, ref := range searchResult.refs {
-		doc, err := searchResult.binocular.Get(ref)
-		if err != nil {
+		doc, ok := searchResult.binocular.docs[ref]
+		if !ok {
 			return nil, ErrRefNotFound
 		}
-		data[i] = doc
+		searchResult.binocular.touch(doc)
+		data[i] = doc.Data
 	}
 	return data, nil
 }
 
-// parses the given document for `binocular` tags and adds them to their respective Index.
-func (binocular *Binocular) parseStruct(id string, doc *document, t reflect.Type) {
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		switch f.Type.Kind() {
-		case reflect.String:
-			tags, err := structtag.Parse(string(f.Tag))
-			if err != nil {
-				break
-			}
-			bt, err := tags.Get("binocular")
-			if err != nil {
-				break
-			}
-			if _, ok := binocular.indices[bt.Name]; !ok {
-				binocular.indices[bt.Name] = NewIndex()
-			}
-			val := reflect.ValueOf(doc.Data).Field(i).String()
-			binocular.indices[bt.Name].Add(val, id)
-			doc.recordLocator[bt.Name] = struct{}{}
-		case reflect.Struct:
-			binocular.parseStruct(id, doc, f.Type)
+// CollectContext is like Collect but returns ctx.Err() if the context is done before the data has been collected.
+func (searchResult *SearchResult) CollectContext(ctx context.Context) (data []interface{}, err error) {
+	_, span := searchResult.binocular.startSpan(ctx, "binocular.Collect", attrHits.Int(len(searchResult.refs)))
+	defer func() { endSpan(span, err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return searchResult.Collect()
+}
+
+// a value of a tagged struct field with the name and FieldType of its Index.
+type fieldValue struct {
+	index string
+	value string
+	typ   FieldType
+}
+
+// resolves the tagged fields of the given struct according to the plan and returns the values with their respective Index.
+func (binocular *Binocular) parseStruct(v reflect.Value, plan *structPlan) ([]fieldValue, error) {
+	fields := make([]fieldValue, 0, len(plan.fields))
+	for _, f := range plan.fields {
+		name, typ, err := binocular.resolveField(f.name, f.typ)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, fieldValue{index: name, value: formatField(v.FieldByIndex(f.index)), typ: typ})
+	}
+	return fields, nil
+}
+
+// creates a new Index which shares the document numbers of the Binocular instance.
+func (binocular *Binocular) newIndex(options ...IndexOption) *Index {
+	index := NewIndex(options...)
+	index.refs = binocular.refs
+	index.sharedRefs = true
+	return index
+}
+
+// returns the Index with the given name and creates it for the FieldType if it does not exist yet.
+// The caller must hold the write lock.
+func (binocular *Binocular) getOrCreateIndex(name string, typ FieldType) *Index {
+	index, ok := binocular.indices[name]
+	if !ok {
+		index = binocular.newIndex(typ.options(nil)...)
+		binocular.instrument(name, index)
+		binocular.indices[name] = index
+		binocular.emit(IndexCreated, "", name)
+	}
+	return index
+}
+
+// compares two slices of values including their order.
+func equalValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
 	}
+	return true
 }
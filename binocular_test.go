@@ -1,6 +1,10 @@
 package binocular
 
-import "testing"
+import (
+	"context"
+	"reflect"
+	"testing"
+)
 
 func TestWithDefaultIndex(t *testing.T) {
 	idxName := "new_default_idx"
@@ -27,11 +31,11 @@ func TestWithIndex(t *testing.T) {
 func TestBinocular_Add_String(t *testing.T) {
 	b := New()
 	testdata := "testdata"
-	id := b.Add(testdata)
+	id, _ := b.Add(testdata)
 	if b.docs[id].Data != testdata {
 		t.Errorf("wrong data")
 	}
-	if b.indices[DefaultIndex].data[testdata][0] != id {
+	if b.indices[DefaultIndex].Postings(testdata)[0] != id {
 		t.Errorf("wrong id")
 	}
 }
@@ -44,7 +48,7 @@ func TestBinocular_AddWithID_String(t *testing.T) {
 	if b.docs[id].Data != testdata {
 		t.Error("wrong data")
 	}
-	if b.indices[DefaultIndex].data[testdata][0] != id {
+	if b.indices[DefaultIndex].Postings(testdata)[0] != id {
 		t.Error("wrong id")
 	}
 }
@@ -74,14 +78,17 @@ func TestBinocular_Add_Struct(t *testing.T) {
 			true,
 		},
 	}
-	id := b.Add(testdata)
+	id, _ := b.Add(testdata)
 	if b.docs[id].Data != testdata {
 		t.Error("wrong data")
 	}
-	if b.indices[DefaultIndex].data["test"][0] != id {
+	if b.indices[DefaultIndex].Postings("test")[0] != id {
 		t.Error("wrong id")
 	}
-	if b.indices["idx1"].data["idx1data"][0] != id {
+	if b.indices["idx1"].Postings("idx1data")[0] != id {
+		t.Error("wrong id")
+	}
+	if b.indices["idx2"].Postings("idx2data")[0] != id {
 		t.Error("wrong id")
 	}
 }
@@ -89,7 +96,7 @@ func TestBinocular_Add_Struct(t *testing.T) {
 func TestBinocular_Get(t *testing.T) {
 	b := New()
 	testdata := "testdata"
-	id := b.Add(testdata)
+	id, _ := b.Add(testdata)
 	data, err := b.Get(id)
 	if err != nil {
 		t.Errorf("unexpected error: %s", err)
@@ -112,7 +119,7 @@ func TestBinocular_Get(t *testing.T) {
 func TestBinocular_Remove(t *testing.T) {
 	b := New()
 	testdata := "testdata"
-	id := b.Add(testdata)
+	id, _ := b.Add(testdata)
 	err := b.Remove(id)
 	if err != nil {
 		t.Errorf("unexpected error: %s", err)
@@ -129,6 +136,88 @@ func TestBinocular_Remove(t *testing.T) {
 	}
 }
 
+func TestBinocular_Remove_ReusesNumbers(t *testing.T) {
+	b := New(WithIndex("other"))
+	for i := 0; i < 100; i++ {
+		if err := b.AddWithID("1", "rocket"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := b.Remove("1"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if len(b.refs.refs) != 1 {
+		t.Errorf("released numbers should be reused, got %d numbers", len(b.refs.refs))
+	}
+
+	// the marker is not part of the record locator but must not be inherited by the next ref
+	_ = b.AddWithID("2", "anvil")
+	b.indices["other"].Add("marker", "2")
+	_ = b.Remove("2")
+	_ = b.AddWithID("3", "rocket")
+	result, err := b.Search("marker", "other")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 0 {
+		t.Errorf("reused number should not match stale postings: %v", result.Refs())
+	}
+}
+
+func TestBinocular_DropIndex(t *testing.T) {
+	b := New()
+	doc := struct {
+		Text  string `binocular:"default"`
+		Title string `binocular:"title"`
+	}{"rocket", "anvil"}
+	if err := b.AddWithID("1", doc); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	usage := b.usage
+	if err := b.DropIndex("title"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := b.indices["title"]; ok {
+		t.Error("index should not exist")
+	}
+	if _, ok := b.docs["1"].recordLocator["title"]; ok {
+		t.Error("record locator should not contain the dropped index")
+	}
+	if b.usage >= usage {
+		t.Error("memory usage should decrease")
+	}
+	if err := b.Remove("1"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := b.DropIndex("title"); err != ErrIndexNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if err := b.DropIndex(DefaultIndex); err != ErrDefaultIndex {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestBinocular_RecordLocator(t *testing.T) {
+	b := New()
+	if err := b.AddWithID("1", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	locator, err := b.RecordLocator("1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(locator, map[string][]string{DefaultIndex: {"rocket"}}) {
+		t.Errorf("wrong record locator: %v", locator)
+	}
+	locator[DefaultIndex][0] = "anvil"
+	if b.docs["1"].recordLocator[DefaultIndex][0] != "rocket" {
+		t.Error("record locator should be a copy")
+	}
+	if _, err := b.RecordLocator("2"); err != ErrRefNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
 func TestBinocular_Search(t *testing.T) {
 	b := New()
 	testdata := "Lorem ipsum dolor sit amet"
@@ -201,7 +290,7 @@ func TestBinocular_FuzzySearch_IndexNotFound(t *testing.T) {
 
 func TestSearchResult_Collect_ErrRefNotFound(t *testing.T) {
 	b := New()
-	id := b.Add("testdata")
+	id, _ := b.Add("testdata")
 	result, err := b.Search("testdata", DefaultIndex)
 	if err != nil {
 		t.Errorf("unexpected error: %s", err)
@@ -221,3 +310,115 @@ func TestSearchResult_Collect_ErrRefNotFound(t *testing.T) {
 		t.Error("data should be nil")
 	}
 }
+
+func TestBinocular_AddWithID_Existing(t *testing.T) {
+	b := New()
+	id := "123"
+	_ = b.AddWithID(id, "Lorem ipsum")
+	err := b.AddWithID(id, "dolor sit amet")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(b.indices[DefaultIndex].Postings("lorem")) != 0 {
+		t.Error("stale term should not match")
+	}
+	if b.indices[DefaultIndex].Postings("dolor")[0] != id {
+		t.Error("wrong id")
+	}
+}
+
+func TestWithUniqueIDs(t *testing.T) {
+	b := New(WithUniqueIDs())
+	id := "123"
+	_ = b.AddWithID(id, "Lorem ipsum")
+	err := b.AddWithID(id, "dolor sit amet")
+	if err != ErrRefExists {
+		t.Errorf("wrong error: %v", err)
+	}
+	if b.docs[id].Data != "Lorem ipsum" {
+		t.Error("data should not have been replaced")
+	}
+}
+
+func TestBinocular_Update(t *testing.T) {
+	type doc struct {
+		Title  string `binocular:"title"`
+		Author string `binocular:"author"`
+		Tag    string `binocular:"tag"`
+	}
+	b := New()
+	id := "123"
+	_ = b.AddWithID(id, doc{"Lorem ipsum", "Cicero", "latin"})
+	// the marker is only kept if the title index is not re-indexed
+	b.indices["title"].Add("marker", id)
+	err := b.Update(id, doc{"Lorem ipsum", "Marcus Tullius Cicero", ""})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(b.indices["title"].Postings("marker")) != 1 {
+		t.Error("unchanged index should not be re-indexed")
+	}
+	if len(b.indices["author"].Postings("marcus")) != 1 || len(b.indices["author"].Postings("cicero")) != 1 {
+		t.Error("changed index should be re-indexed")
+	}
+	if len(b.indices["tag"].Postings("latin")) != 0 {
+		t.Error("stale term should not match")
+	}
+	err = b.Update("unknown_id", doc{})
+	if err != ErrRefNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if b.docs["unknown_id"] != nil {
+		t.Error("document should not exist")
+	}
+}
+
+func TestBinocular_Upsert(t *testing.T) {
+	b := New()
+	id := "123"
+	err := b.Upsert(id, "Lorem ipsum")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if b.indices[DefaultIndex].Postings("lorem")[0] != id {
+		t.Error("wrong id")
+	}
+	err = b.Upsert(id, "dolor sit amet")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(b.indices[DefaultIndex].Postings("lorem")) != 0 {
+		t.Error("stale term should not match")
+	}
+	if b.docs[id].Data != "dolor sit amet" {
+		t.Error("wrong data")
+	}
+}
+
+func TestBinocular_SearchContext_Cancelled(t *testing.T) {
+	b := New()
+	_, _ = b.Add("Lorem ipsum dolor sit amet")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := b.SearchContext(ctx, "ipsum", DefaultIndex)
+	if err != context.Canceled {
+		t.Errorf("wrong error: %v", err)
+	}
+	if result != nil {
+		t.Error("result should be nil")
+	}
+	result, err = b.FuzzySearchContext(ctx, "ipm", DefaultIndex, 3)
+	if err != context.Canceled {
+		t.Errorf("wrong error: %v", err)
+	}
+	if result != nil {
+		t.Error("result should be nil")
+	}
+	result, err = b.SearchContext(context.Background(), "ipsum", DefaultIndex)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 1 {
+		t.Error("wrong result len")
+	}
+}
@@ -0,0 +1,166 @@
+package binocular
+
+import (
+	"container/heap"
+)
+
+// EvictionPolicy determines which documents are evicted when the capacity of a Binocular instance is exceeded.
+type EvictionPolicy int
+
+const (
+	// EvictLRU evicts the least recently used documents first.
+	EvictLRU EvictionPolicy = iota
+	// EvictLFU evicts the least frequently used documents first, the least recently used of those first.
+	EvictLFU
+)
+
+// WithCapacity limits the number of documents to n. If a new document exceeds the capacity,
+// documents are evicted according to the policy. Adding, Get and Collect count as use of a document.
+// If a Batch exceeds the capacity on its own, only its last n documents are kept.
+func WithCapacity(n int, policy EvictionPolicy) Option {
+	return func(binocular *Binocular) {
+		binocular.capacity = n
+		binocular.eviction = policy
+	}
+}
+
+// WithEvictionCallback calls fn with the id and data of every document evicted because of
+// WithCapacity or WithMemoryLimit. It is called after the write lock has been released and may change the instance.
+func WithEvictionCallback(fn func(id string, data interface{})) Option {
+	return func(binocular *Binocular) {
+		binocular.onEvict = fn
+	}
+}
+
+// evicted is a document which has been evicted while holding the write lock.
+type evicted struct {
+	id   string
+	data interface{}
+}
+
+// evict removes the existing id and queues it for the eviction callback. The caller must hold the write lock.
+func (binocular *Binocular) evict(id string) {
+	if binocular.onEvict != nil {
+		binocular.evicted = append(binocular.evicted, evicted{id: id, data: binocular.docs[id].Data})
+	}
+	binocular.remove(id)
+}
+
+// enforceCapacity evicts documents until the capacity is no longer exceeded. The given ids are never evicted.
+// The caller must hold the write lock.
+func (binocular *Binocular) enforceCapacity(keep map[string]struct{}) {
+	if binocular.capacity <= 0 {
+		return
+	}
+	binocular.accessMut.Lock()
+	kept := make([]*accessEntry, 0)
+	victims := make([]string, 0)
+	for len(binocular.docs)-len(victims) > binocular.capacity && binocular.accesses.Len() > 0 {
+		entry := heap.Pop(&binocular.accesses).(*accessEntry)
+		if _, ok := keep[entry.id]; ok {
+			kept = append(kept, entry)
+			continue
+		}
+		victims = append(victims, entry.id)
+	}
+	for _, entry := range kept {
+		heap.Push(&binocular.accesses, entry)
+	}
+	binocular.accessMut.Unlock()
+	for _, id := range victims {
+		binocular.evict(id)
+	}
+}
+
+// accessEntry tracks the use of a document for the EvictionPolicy.
+type accessEntry struct {
+	id   string
+	hits uint64
+	tick uint64
+	pos  int
+}
+
+// accessQueue is a min-heap of documents ordered by the next to evict.
+type accessQueue struct {
+	entries []*accessEntry
+	lfu     bool
+}
+
+func (q accessQueue) Len() int { return len(q.entries) }
+
+func (q accessQueue) Less(i, j int) bool {
+	a, b := q.entries[i], q.entries[j]
+	if q.lfu && a.hits != b.hits {
+		return a.hits < b.hits
+	}
+	return a.tick < b.tick
+}
+
+func (q accessQueue) Swap(i, j int) {
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	q.entries[i].pos = i
+	q.entries[j].pos = j
+}
+
+func (q *accessQueue) Push(x interface{}) {
+	entry := x.(*accessEntry)
+	entry.pos = len(q.entries)
+	q.entries = append(q.entries, entry)
+}
+
+func (q *accessQueue) Pop() interface{} {
+	old := q.entries
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.pos = -1
+	q.entries = old[:n-1]
+	return entry
+}
+
+// trackAccess starts tracking the use of the document or continues the tracking of the old one.
+// The caller must hold the write lock.
+func (binocular *Binocular) trackAccess(id string, old *document, doc *document) {
+	if binocular.capacity <= 0 {
+		return
+	}
+	if old != nil && old.access != nil {
+		doc.access = old.access
+		binocular.touch(doc)
+		return
+	}
+	binocular.accessMut.Lock()
+	defer binocular.accessMut.Unlock()
+	binocular.accesses.lfu = binocular.eviction == EvictLFU
+	binocular.ticks++
+	doc.access = &accessEntry{id: id, tick: binocular.ticks}
+	heap.Push(&binocular.accesses, doc.access)
+}
+
+// untrackAccess stops tracking the use of the document. The caller must hold the write lock.
+func (binocular *Binocular) untrackAccess(doc *document) {
+	if doc.access == nil {
+		return
+	}
+	binocular.accessMut.Lock()
+	defer binocular.accessMut.Unlock()
+	if doc.access.pos >= 0 {
+		heap.Remove(&binocular.accesses, doc.access.pos)
+	}
+}
+
+// touch records a use of the document. The caller must hold the read or write lock.
+func (binocular *Binocular) touch(doc *document) {
+	if doc.access == nil {
+		return
+	}
+	binocular.accessMut.Lock()
+	defer binocular.accessMut.Unlock()
+	if doc.access.pos < 0 {
+		return
+	}
+	binocular.ticks++
+	doc.access.hits++
+	doc.access.tick = binocular.ticks
+	heap.Fix(&binocular.accesses, doc.access.pos)
+}
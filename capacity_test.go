@@ -0,0 +1,174 @@
+package binocular
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestWithCapacity(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  EvictionPolicy
+		use     func(b *Binocular)
+		evicted []string
+	}{
+		{
+			"lru get",
+			EvictLRU,
+			func(b *Binocular) {
+				b.Get("1")
+			},
+			[]string{"2"},
+		},
+		{
+			"lru collect",
+			EvictLRU,
+			func(b *Binocular) {
+				result, _ := b.Search("one", DefaultIndex)
+				result.Collect()
+			},
+			[]string{"2"},
+		},
+		{
+			"lru without use",
+			EvictLRU,
+			func(b *Binocular) {},
+			[]string{"1"},
+		},
+		{
+			"lfu",
+			EvictLFU,
+			func(b *Binocular) {
+				b.Get("2")
+				b.Get("1")
+				b.Get("2")
+			},
+			[]string{"1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evicted := make([]string, 0)
+			b := New(
+				WithCapacity(2, tt.policy),
+				WithEvictionCallback(func(id string, data interface{}) {
+					evicted = append(evicted, id)
+				}),
+			)
+			b.AddWithID("1", "rocket one")
+			b.AddWithID("2", "rocket two")
+			tt.use(b)
+			if err := b.AddWithID("3", "rocket three"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(evicted, tt.evicted) {
+				t.Errorf("expected %v to be evicted, got %v", tt.evicted, evicted)
+			}
+			if len(b.docs) != 2 {
+				t.Errorf("wrong number of documents: %d", len(b.docs))
+			}
+			result, err := b.Search("rocket", DefaultIndex)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			for _, ref := range result.Refs() {
+				if ref == tt.evicted[0] {
+					t.Error("evicted document should be removed from indices")
+				}
+			}
+		})
+	}
+}
+
+func TestWithCapacity_Batch(t *testing.T) {
+	b := New(WithCapacity(2, EvictLFU))
+	b.AddWithID("1", "rocket")
+	b.Get("1")
+	batch := b.NewBatch()
+	batch.AddWithID("2", "rocket")
+	batch.AddWithID("3", "rocket")
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ids := make([]string, 0)
+	for id := range b.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"2", "3"}) {
+		t.Errorf("documents of the batch should be kept: %v", ids)
+	}
+}
+
+func TestWithCapacity_LargeBatch(t *testing.T) {
+	b := New(WithCapacity(2, EvictLRU))
+	b.AddWithID("1", "rocket")
+	batch := b.NewBatch()
+	for _, id := range []string{"2", "3", "4", "5", "6"} {
+		batch.AddWithID(id, "rocket")
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ids := make([]string, 0)
+	for id := range b.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"5", "6"}) {
+		t.Errorf("only the newest documents of the batch should be kept: %v", ids)
+	}
+}
+
+func TestWithEvictionCallback_MemoryLimit(t *testing.T) {
+	evicted := make([]string, 0)
+	b := New(
+		WithMemoryLimit(1, EvictOnLimit),
+		WithEvictionCallback(func(id string, data interface{}) {
+			evicted = append(evicted, id)
+		}),
+	)
+	b.memoryLimit = 0
+	b.AddWithID("1", "rocket")
+	b.memoryLimit = b.usage + 1
+	if err := b.AddWithID("2", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(evicted, []string{"1"}) {
+		t.Errorf("wrong evicted documents: %v", evicted)
+	}
+}
+
+func TestWithEvictionCallback_Write(t *testing.T) {
+	var b *Binocular
+	b = New(
+		WithCapacity(2, EvictLRU),
+		WithHook(func(event Event) {}),
+		WithEvictionCallback(func(id string, data interface{}) {
+			// eviction callbacks may change the instance
+			b.Remove("2")
+		}),
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, id := range []string{"1", "2", "3"} {
+			if err := b.AddWithID(id, "rocket"); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("an eviction callback changing the instance should not deadlock")
+	}
+	if _, err := b.Get("2"); err != ErrRefNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := b.Get("3"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
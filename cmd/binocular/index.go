@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mycreepy/go-binocular"
+)
+
+// runs the index command which ingests files and writes a snapshot.
+func runIndex(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("index", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "", "format of the files: text, json or csv (default: by file extension)")
+	output := fs.String("o", "binocular.snapshot", "path of the snapshot to write")
+	idField := fs.String("id", "", "field holding the id of JSON and CSV documents (default: line or record number counted across all files)")
+	stemming := fs.Bool("stemming", false, "enable stemming")
+	stopWords := fs.Bool("stop-words", false, "index stop words")
+	shortWords := fs.Bool("short-words", false, "index short words")
+	m := mappings{}
+	fs.Var(m, "map", "map a JSON path or CSV column to an index as path=index, may be repeated (CSV default: every column to an index of the same name)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: binocular index [flags] file...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: no files given", errUsage)
+	}
+
+	var indexOptions []binocular.IndexOption
+	if *stemming {
+		indexOptions = append(indexOptions, binocular.WithStemming())
+	}
+	if *stopWords {
+		indexOptions = append(indexOptions, binocular.WithStopWords())
+	}
+	if *shortWords {
+		indexOptions = append(indexOptions, binocular.WithShortWords())
+	}
+
+	files := make([]ingestFile, fs.NArg())
+	for i, path := range fs.Args() {
+		files[i] = ingestFile{path: path, format: *format}
+		if files[i].format == "" {
+			files[i].format = formatOf(path)
+		}
+		if files[i].format == "json" && len(m) == 0 {
+			return fmt.Errorf("%w: JSON files need at least one -map", errUsage)
+		}
+		if files[i].format == "csv" && len(m) == 0 {
+			columns, err := csvHeader(path)
+			if err != nil {
+				return err
+			}
+			for _, column := range columns {
+				m[column] = column
+			}
+		}
+	}
+
+	options := []binocular.Option{binocular.WithDefaultIndex(binocular.DefaultIndex, indexOptions...)}
+	for path, index := range m {
+		if index != binocular.DefaultIndex {
+			options = append(options, binocular.WithIndex(index, indexOptions...))
+		}
+		options = append(options, binocular.WithFieldMapping(path, index))
+	}
+	b := binocular.New(options...)
+	seq := 0
+	for _, file := range files {
+		if err := ingest(b, file, *idField, &seq); err != nil {
+			return fmt.Errorf("%s: %w", file.path, err)
+		}
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := b.SnapshotContext(context.Background(), out); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "indexed %d documents into %s\n", b.Stats().Docs, *output)
+	return nil
+}
+
+// ingestFile is a file with the format of its documents.
+type ingestFile struct {
+	path   string
+	format string
+}
+
+// returns the format of the file by its extension.
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jsonl", ".ndjson":
+		return "json"
+	case ".csv":
+		return "csv"
+	default:
+		return "text"
+	}
+}
+
+// adds the documents of the file to the Binocular instance.
+// Seq is the running line or record number used as default id across all files.
+func ingest(b *binocular.Binocular, file ingestFile, idField string, seq *int) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	batch := b.NewBatch()
+	switch file.format {
+	case "text":
+		err = ingestText(batch, f, seq)
+	case "json":
+		err = ingestJSON(batch, f, idField, seq)
+	case "csv":
+		err = ingestCSV(batch, f, idField, seq)
+	default:
+		return fmt.Errorf("%w: unknown format %q", errUsage, file.format)
+	}
+	if err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// adds every non-empty line as a document with the running line number as id.
+func ingestText(batch *binocular.Batch, r io.Reader, seq *int) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		*seq++
+		if text := strings.TrimSpace(scanner.Text()); text != "" {
+			batch.AddWithID(strconv.Itoa(*seq), text)
+		}
+	}
+	return scanner.Err()
+}
+
+// adds every JSON value of a newline-delimited JSON stream or the elements of a JSON array as a document.
+func ingestJSON(batch *binocular.Batch, r io.Reader, idField string, seq *int) error {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	first, err := firstNonSpace(br)
+	if err != nil {
+		return err
+	}
+	if first == '[' {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	for dec.More() {
+		var doc json.RawMessage
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+		*seq++
+		id := strconv.Itoa(*seq)
+		if idField != "" {
+			// numbers are kept as written so large numeric ids are not formatted as floats
+			var fields map[string]interface{}
+			fieldDec := json.NewDecoder(bytes.NewReader(doc))
+			fieldDec.UseNumber()
+			if err := fieldDec.Decode(&fields); err == nil {
+				if v, ok := fields[idField]; ok {
+					id = fmt.Sprint(v)
+				}
+			}
+		}
+		batch.AddWithID(id, doc)
+	}
+	return nil
+}
+
+// returns the first byte which is not white space without consuming it.
+func firstNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// adds every record of a CSV file with a header as a document with a field per column.
+func ingestCSV(batch *binocular.Batch, r io.Reader, idField string, seq *int) error {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		doc := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			doc[column] = record[i]
+		}
+		*seq++
+		id := strconv.Itoa(*seq)
+		if v, ok := doc[idField].(string); ok && idField != "" {
+			id = v
+		}
+		batch.AddWithID(id, doc)
+	}
+}
+
+// returns the header of a CSV file.
+func csvHeader(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	header, err := csv.NewReader(f).Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	return header, err
+}
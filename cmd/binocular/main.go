@@ -0,0 +1,76 @@
+// Command binocular builds snapshots of Binocular instances from files and searches them.
+//
+// Usage:
+//
+//	binocular index [flags] file...
+//	binocular search [flags] word
+//	binocular repl [flags]
+//
+// Run a command with -h to see its flags.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "binocular:", err)
+		os.Exit(1)
+	}
+}
+
+const usage = `usage:
+  binocular index [flags] file...   ingest text, JSON or CSV files and write a snapshot
+  binocular search [flags] word     search a snapshot
+  binocular repl [flags]            explore a snapshot interactively
+`
+
+// errUsage indicates that the command line arguments are invalid.
+var errUsage = errors.New("invalid arguments")
+
+// runs the command given by args.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+	switch args[0] {
+	case "index":
+		return runIndex(args[1:], stdout, stderr)
+	case "search":
+		return runSearch(args[1:], stdout, stderr)
+	case "repl":
+		return runREPL(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+}
+
+// mappings collects repeated -map flags of the form path=index.
+type mappings map[string]string
+
+func (m mappings) String() string {
+	parts := make([]string, 0, len(m))
+	for path, index := range m {
+		parts = append(parts, path+"="+index)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (m mappings) Set(value string) error {
+	path, index, ok := strings.Cut(value, "=")
+	if !ok || path == "" || index == "" {
+		return errors.New("expected path=index")
+	}
+	m[path] = index
+	return nil
+}
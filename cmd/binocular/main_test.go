@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writes the files into a temporary directory and returns their paths by name.
+func writeTestFiles(t *testing.T, files map[string]string) map[string]string {
+	dir := t.TempDir()
+	paths := make(map[string]string, len(files))
+	for name, content := range files {
+		paths[name] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[name], []byte(content), 0o600); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	paths["snapshot"] = filepath.Join(dir, "test.snapshot")
+	return paths
+}
+
+func runTest(t *testing.T, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	err := run(args, strings.NewReader(""), &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestRun_Text(t *testing.T) {
+	paths := writeTestFiles(t, map[string]string{
+		"quotes.txt": "Houston we have a problem\n\nThat's one small step for man\nHouston we had a problem\n",
+	})
+	out, err := runTest(t, "index", "-stemming", "-o", paths["snapshot"], paths["quotes.txt"])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out, "indexed 3 documents") {
+		t.Errorf("wrong output: %s", out)
+	}
+
+	out, err = runTest(t, "search", "-snapshot", paths["snapshot"], "-docs", "problems")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "REF  SCORE   DOCUMENT\n" +
+		"1    0.9163  Houston we have a problem\n" +
+		"4    0.9163  Houston we had a problem\n"
+	if out != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out)
+	}
+}
+
+func TestRun_Text_MultipleFiles(t *testing.T) {
+	paths := writeTestFiles(t, map[string]string{
+		"apollo.txt": "Houston we have a problem\nThe Eagle has landed\n",
+		"gemini.txt": "Houston we had a problem\n",
+	})
+	out, err := runTest(t, "index", "-o", paths["snapshot"], paths["apollo.txt"], paths["gemini.txt"])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out, "indexed 3 documents") {
+		t.Errorf("wrong output: %s", out)
+	}
+
+	out, err = runTest(t, "search", "-snapshot", paths["snapshot"], "houston")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out, "\n1 ") || !strings.Contains(out, "\n3 ") {
+		t.Errorf("documents of both files should be kept: %s", out)
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	paths := writeTestFiles(t, map[string]string{
+		"movies.ndjson": `{"id": "brian", "title": "Life of Brian", "genre": "comedy"}
+{"id": "grail", "title": "Monty Python and the Holy Grail", "genre": "comedy"}
+{"id": "apollo", "title": "Apollo 13", "genre": "drama"}
+`,
+		"array.json": `[{"id": "meaning", "title": "The Meaning of Life", "genre": "comedy"}]`,
+	})
+	_, err := runTest(t, "index", "-id", "id", "-map", "title=default", "-map", "genre=genre",
+		"-o", paths["snapshot"], paths["movies.ndjson"], paths["array.json"])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	out, err := runTest(t, "search", "-snapshot", paths["snapshot"], "-output", "json", "-filter", "genre:comedy", "-docs", "life")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var hits []searchHit
+	if err := json.Unmarshal([]byte(out), &hits); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	refs := make([]string, len(hits))
+	for i, hit := range hits {
+		refs[i] = hit.Ref
+	}
+	if !reflect.DeepEqual(refs, []string{"brian", "meaning"}) {
+		t.Errorf("wrong refs: %v", refs)
+	}
+	if hits[0].Document.(map[string]interface{})["title"] != "Life of Brian" {
+		t.Errorf("wrong document: %v", hits[0].Document)
+	}
+
+	if _, err := runTest(t, "index", "-o", paths["snapshot"], paths["array.json"]); !errors.Is(err, errUsage) {
+		t.Errorf("JSON without mapping should fail: %v", err)
+	}
+}
+
+func TestRun_JSON_NumericID(t *testing.T) {
+	paths := writeTestFiles(t, map[string]string{
+		"movies.ndjson": `{"id": 1234567, "title": "Life of Brian"}` + "\n",
+	})
+	_, err := runTest(t, "index", "-id", "id", "-map", "title=default", "-o", paths["snapshot"], paths["movies.ndjson"])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	out, err := runTest(t, "search", "-snapshot", paths["snapshot"], "-output", "json", "brian")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var hits []searchHit
+	if err := json.Unmarshal([]byte(out), &hits); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(hits) != 1 || hits[0].Ref != "1234567" {
+		t.Errorf("numeric id should be kept as written: %v", hits)
+	}
+}
+
+func TestRun_CSV(t *testing.T) {
+	paths := writeTestFiles(t, map[string]string{
+		"astronauts.csv": "name,mission\nJim Lovell,Apollo 13\nNeil Armstrong,Apollo 11\nBuzz Aldrin,Apollo 11\n",
+	})
+	if _, err := runTest(t, "index", "-o", paths["snapshot"], paths["astronauts.csv"]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	out, err := runTest(t, "search", "-snapshot", paths["snapshot"], "-index", "name", "-distance", "1", "-limit", "1", "lovel")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out, "\n1 ") || strings.Count(out, "\n") != 2 {
+		t.Errorf("wrong output: %s", out)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	paths := writeTestFiles(t, map[string]string{"quotes.txt": "rocket"})
+	if _, err := runTest(t, "index", "-o", paths["snapshot"], paths["quotes.txt"]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tests := []struct {
+		name  string
+		args  []string
+		usage bool
+	}{
+		{"no command", nil, true},
+		{"unknown command", []string{"unknown"}, true},
+		{"index without files", []string{"index"}, true},
+		{"index missing file", []string{"index", "-o", paths["snapshot"], "missing.txt"}, false},
+		{"index invalid mapping", []string{"index", "-map", "title", paths["quotes.txt"]}, true},
+		{"search without word", []string{"search", "-snapshot", paths["snapshot"]}, true},
+		{"search unknown output", []string{"search", "-snapshot", paths["snapshot"], "-output", "xml", "rocket"}, true},
+		{"search missing snapshot", []string{"search", "-snapshot", "missing.snapshot", "rocket"}, false},
+		{"search unknown index", []string{"search", "-snapshot", paths["snapshot"], "-index", "unknown", "rocket"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runTest(t, tt.args...)
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if errors.Is(err, errUsage) != tt.usage {
+				t.Errorf("wrong error: %v", err)
+			}
+		})
+	}
+}
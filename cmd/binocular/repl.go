@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mycreepy/go-binocular"
+)
+
+const replHelp = `commands:
+  search <index> <word>               search an index
+  fuzzy <index> <distance> <word>     search an index within a Levenshtein distance
+  analyze <index> <text>              show the terms the text is stored as and the dropped words
+  postings <index> <term>             show the refs stored for an exact term
+  doc <ref>                           show the values a ref has been added with to each index
+  indices                             list all indices
+  stats                               show statistics
+  help                                show this help
+  quit                                leave the REPL
+`
+
+// errQuit indicates that the REPL should be left.
+var errQuit = errors.New("quit")
+
+// runs the repl command which explores a snapshot interactively.
+func runREPL(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	snapshot := fs.String("snapshot", "binocular.snapshot", "path of the snapshot to explore")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: binocular repl [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	b, err := loadSnapshot(*snapshot)
+	if err != nil {
+		return err
+	}
+	repl := &repl{binocular: b, out: stdout}
+	fmt.Fprintf(stdout, "loaded %s, type help for a list of commands\n", *snapshot)
+	scanner := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprint(stdout, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(stdout)
+			return scanner.Err()
+		}
+		err := repl.eval(scanner.Text())
+		if errors.Is(err, errQuit) {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintln(stdout, "error:", err)
+		}
+	}
+}
+
+// repl evaluates the commands of a REPL session against a Binocular instance.
+type repl struct {
+	binocular *binocular.Binocular
+	out       io.Writer
+}
+
+// evaluates a single line and prints the result.
+func (repl *repl) eval(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "search":
+		if len(args) != 2 {
+			return errors.New("usage: search <index> <word>")
+		}
+		return repl.search(args[0], args[1], 0)
+	case "fuzzy":
+		if len(args) != 3 {
+			return errors.New("usage: fuzzy <index> <distance> <word>")
+		}
+		distance, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid distance %q", args[1])
+		}
+		return repl.search(args[0], args[2], distance)
+	case "analyze":
+		if len(args) < 2 {
+			return errors.New("usage: analyze <index> <text>")
+		}
+		return repl.analyze(args[0], strings.Join(args[1:], " "))
+	case "postings":
+		if len(args) < 2 {
+			return errors.New("usage: postings <index> <term>")
+		}
+		return repl.postings(args[0], strings.Join(args[1:], " "))
+	case "doc":
+		if len(args) != 1 {
+			return errors.New("usage: doc <ref>")
+		}
+		return repl.doc(args[0])
+	case "indices":
+		for _, name := range repl.binocular.Indices() {
+			fmt.Fprintln(repl.out, name)
+		}
+		return nil
+	case "stats":
+		return printJSON(repl.out, repl.binocular.Stats())
+	case "help":
+		fmt.Fprint(repl.out, replHelp)
+		return nil
+	case "quit", "exit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command %q, type help for a list of commands", cmd)
+	}
+}
+
+func (repl *repl) search(index string, word string, distance int) error {
+	result, err := repl.binocular.FuzzySearch(word, index, distance)
+	if err != nil {
+		return err
+	}
+	hits, err := collectHits(repl.binocular, result, true, 0)
+	if err != nil {
+		return err
+	}
+	return printTable(repl.out, hits, true)
+}
+
+func (repl *repl) analyze(name string, text string) error {
+	index, err := repl.binocular.Index(name)
+	if err != nil {
+		return err
+	}
+	terms, dropped := make([]string, 0), make([]string, 0)
+	for _, token := range index.Analyze(text) {
+		if token.Dropped() {
+			dropped = append(dropped, fmt.Sprintf("%s (%s)", token.Text, token.DroppedBy))
+			continue
+		}
+		terms = append(terms, token.Term)
+	}
+	fmt.Fprintln(repl.out, strings.Join(terms, " "))
+	if len(dropped) > 0 {
+		fmt.Fprintf(repl.out, "dropped: %s\n", strings.Join(dropped, ", "))
+	}
+	return nil
+}
+
+func (repl *repl) postings(name string, term string) error {
+	index, err := repl.binocular.Index(name)
+	if err != nil {
+		return err
+	}
+	refs := index.Postings(term)
+	sort.Strings(refs)
+	fmt.Fprintf(repl.out, "%d refs: %s\n", len(refs), strings.Join(refs, " "))
+	return nil
+}
+
+func (repl *repl) doc(ref string) error {
+	locator, err := repl.binocular.RecordLocator(ref)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(locator))
+	for name := range locator {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range locator[name] {
+			fmt.Fprintf(repl.out, "%s: %s\n", name, value)
+		}
+	}
+	return nil
+}
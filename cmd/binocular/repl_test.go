@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun_REPL(t *testing.T) {
+	paths := writeTestFiles(t, map[string]string{
+		"quotes.txt": "Houston we have a problem\nHouston we had problems\n",
+	})
+	if _, err := runTest(t, "index", "-stemming", "-o", paths["snapshot"], paths["quotes.txt"]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"search", "search default problem", "1    0.6931  Houston we have a problem\n"},
+		{"fuzzy", "fuzzy default 1 houstn", "2    0.6931  Houston we had problems\n"},
+		{"analyze", "analyze default We had Problems!", "we had problem\n> "},
+		{"postings", "postings default problem", "2 refs: 1 2\n"},
+		{"postings unanalyzed", "postings default problems", "0 refs: \n"},
+		{"doc", "doc 2", "default: Houston we had problems\n"},
+		{"indices", "indices", "default\n"},
+		{"help", "help", "commands:"},
+		{"unknown index", "search unknown problem", "error: index not found\n"},
+		{"unknown ref", "doc 3", "error: ref not found\n"},
+		{"unknown command", "explain", "error: unknown command \"explain\""},
+		{"usage", "fuzzy default problem", "error: usage: fuzzy <index> <distance> <word>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			input := strings.NewReader(tt.input + "\nquit\nsearch default problem\n")
+			if err := run([]string{"repl", "-snapshot", paths["snapshot"]}, input, &stdout, &stderr); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Errorf("expected output to contain %q, got\n%s", tt.want, stdout.String())
+			}
+			if strings.Count(stdout.String(), "\n> ") != 2 {
+				t.Error("the REPL should be left after quit")
+			}
+		})
+	}
+}
+
+func TestRun_REPL_EOF(t *testing.T) {
+	paths := writeTestFiles(t, map[string]string{"quotes.txt": "rocket"})
+	if _, err := runTest(t, "index", "-o", paths["snapshot"], paths["quotes.txt"]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"repl", "-snapshot", paths["snapshot"]}, strings.NewReader("indices"), &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(stdout.String(), "default\n") {
+		t.Errorf("wrong output: %s", stdout.String())
+	}
+}
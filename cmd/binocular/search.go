@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mycreepy/go-binocular"
+)
+
+// filters collects repeated -filter flags of the form index:value.
+type filters []binocular.Filter
+
+func (f *filters) String() string {
+	parts := make([]string, len(*f))
+	for i, filter := range *f {
+		parts[i] = filter.Index + ":" + filter.Value
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *filters) Set(value string) error {
+	index, v, ok := strings.Cut(value, ":")
+	if !ok || index == "" {
+		return fmt.Errorf("expected index:value")
+	}
+	*f = append(*f, binocular.Filter{Index: index, Value: v})
+	return nil
+}
+
+// searchHit is a hit of a search as it is printed.
+type searchHit struct {
+	Ref      string      `json:"ref"`
+	Score    float64     `json:"score"`
+	Document interface{} `json:"document,omitempty"`
+}
+
+// runs the search command which searches a snapshot.
+func runSearch(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	snapshot := fs.String("snapshot", "binocular.snapshot", "path of the snapshot to search")
+	index := fs.String("index", binocular.DefaultIndex, "index to search")
+	distance := fs.Int("distance", 0, "Levenshtein distance for a fuzzy search")
+	output := fs.String("output", "table", "output format: table or json")
+	docs := fs.Bool("docs", false, "print the documents of the found refs")
+	limit := fs.Int("limit", 0, "maximum number of hits to print (default: all)")
+	var f filters
+	fs.Var(&f, "filter", "restrict the search to documents with the value in an index as index:value, may be repeated")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: binocular search [flags] word")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("%w: expected exactly one word", errUsage)
+	}
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("%w: unknown output format %q", errUsage, *output)
+	}
+
+	b, err := loadSnapshot(*snapshot)
+	if err != nil {
+		return err
+	}
+	result, err := b.FuzzySearch(fs.Arg(0), *index, *distance, f...)
+	if err != nil {
+		return err
+	}
+	hits, err := collectHits(b, result, *docs, *limit)
+	if err != nil {
+		return err
+	}
+	if *output == "json" {
+		return printJSON(stdout, hits)
+	}
+	return printTable(stdout, hits, *docs)
+}
+
+// creates a new Binocular instance from the snapshot at the given path.
+func loadSnapshot(path string) (*binocular.Binocular, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b := binocular.New()
+	if err := b.RestoreContext(context.Background(), f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// returns up to limit hits of the result, the most relevant first, and their documents if requested.
+func collectHits(b *binocular.Binocular, result *binocular.SearchResult, docs bool, limit int) ([]searchHit, error) {
+	found := result.Hits()
+	if limit > 0 && limit < len(found) {
+		found = found[:limit]
+	}
+	hits := make([]searchHit, len(found))
+	for i, hit := range found {
+		hits[i] = searchHit{Ref: hit.Ref, Score: hit.Score}
+		if !docs {
+			continue
+		}
+		data, err := b.Get(hit.Ref)
+		if err != nil {
+			return nil, err
+		}
+		hits[i].Document = data
+	}
+	return hits, nil
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// prints the hits as table with a column for the documents if requested.
+func printTable(w io.Writer, hits []searchHit, docs bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if docs {
+		fmt.Fprintln(tw, "REF\tSCORE\tDOCUMENT")
+	} else {
+		fmt.Fprintln(tw, "REF\tSCORE")
+	}
+	for _, hit := range hits {
+		if !docs {
+			fmt.Fprintf(tw, "%s\t%.4f\n", hit.Ref, hit.Score)
+			continue
+		}
+		doc, err := formatDocument(hit.Document)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%.4f\t%s\n", hit.Ref, hit.Score, doc)
+	}
+	return tw.Flush()
+}
+
+// formats a document on a single line, strings are printed as they are and everything else as JSON.
+func formatDocument(doc interface{}) (string, error) {
+	switch v := doc.(type) {
+	case string:
+		return v, nil
+	case json.RawMessage:
+		return string(v), nil
+	}
+	data, err := json.Marshal(doc)
+	return string(data), err
+}
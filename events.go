@@ -0,0 +1,310 @@
+package binocular
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// EventType is the kind of change an Event reports.
+type EventType int
+
+const (
+	// DocumentAdded is fired after a document with a new id has been added.
+	DocumentAdded EventType = iota
+	// DocumentUpdated is fired after the document of an existing id has been replaced.
+	DocumentUpdated
+	// DocumentRemoved is fired after a document has been removed, including evictions.
+	DocumentRemoved
+	// IndexCreated is fired after an Index has been created on demand while adding data.
+	IndexCreated
+	// IndexDropped is fired after an Index has been dropped.
+	IndexDropped
+	// TenantDropped is fired after a tenant has been dropped with all of its documents and indices,
+	// no DocumentRemoved or IndexDropped events are fired for them.
+	TenantDropped
+)
+
+func (typ EventType) String() string {
+	switch typ {
+	case DocumentAdded:
+		return "document_added"
+	case DocumentUpdated:
+		return "document_updated"
+	case DocumentRemoved:
+		return "document_removed"
+	case IndexCreated:
+		return "index_created"
+	case IndexDropped:
+		return "index_dropped"
+	case TenantDropped:
+		return "tenant_dropped"
+	default:
+		return "unknown"
+	}
+}
+
+// Event reports a change of a Binocular instance.
+// Ref is set for document events and Index for index events.
+// Tenant is the name of the tenant the change happened in or empty for the instance itself.
+type Event struct {
+	Type   EventType
+	Ref    string
+	Index  string
+	Tenant string
+}
+
+// BackpressurePolicy determines what happens when the buffer of a Subscription is full.
+type BackpressurePolicy int
+
+const (
+	// BlockOnFull blocks further changes of the Binocular instance until the subscriber has caught up,
+	// so the subscriber must not change the instance while it is behind.
+	BlockOnFull BackpressurePolicy = iota
+	// DropNewest discards events which do not fit into the buffer.
+	DropNewest
+	// DropOldest discards the oldest buffered event to make room for a new one.
+	DropOldest
+)
+
+// WithHook calls the hook for every change of the Binocular instance and its tenants.
+// Indices created by Options like WithIndex or WithSchema do not fire events.
+// Hooks are called in the order of the changes after the write lock has been released, and a change returns
+// once its events have been dispatched. Hooks may use and change the Binocular instance and its tenants,
+// changes made by a hook are dispatched after the hook returns.
+// RestoreContext replaces all data without firing events.
+func WithHook(hook func(event Event)) Option {
+	return func(binocular *Binocular) {
+		binocular.events.hooks = append(binocular.events.hooks, hook)
+	}
+}
+
+// eventBus dispatches the events of a Binocular instance to its hooks and subscriptions.
+type eventBus struct {
+	// queueMut guards the queue of deliveries which keeps the order of events of concurrent changes.
+	queueMut sync.Mutex
+	queue    []*delivery
+	// dispatcher is the id of the goroutine draining the queue or zero, changes it makes from
+	// hooks or eviction callbacks do not wait for their delivery.
+	dispatcher uint64
+
+	mut   sync.RWMutex
+	hooks []func(event Event)
+	subs  map[*Subscription]struct{}
+}
+
+// delivery are the events and evicted documents of one change.
+type delivery struct {
+	events  []Event
+	evicted []evicted
+	onEvict func(id string, data interface{})
+	done    chan struct{}
+}
+
+// active reports whether any hook or subscription has to be notified.
+func (bus *eventBus) active() bool {
+	bus.mut.RLock()
+	defer bus.mut.RUnlock()
+	return len(bus.hooks) > 0 || len(bus.subs) > 0
+}
+
+// dispatch notifies all hooks and subscriptions of the events.
+func (bus *eventBus) dispatch(events []Event) {
+	bus.mut.RLock()
+	hooks := bus.hooks
+	subs := make([]*Subscription, 0, len(bus.subs))
+	for sub := range bus.subs {
+		subs = append(subs, sub)
+	}
+	bus.mut.RUnlock()
+	for _, event := range events {
+		for _, hook := range hooks {
+			hook(event)
+		}
+		for _, sub := range subs {
+			sub.send(event)
+		}
+	}
+}
+
+// emit queues the event to be dispatched once the write lock is released. The caller must hold the write lock.
+func (binocular *Binocular) emit(typ EventType, ref string, index string) {
+	if !binocular.events.active() {
+		return
+	}
+	binocular.pending = append(binocular.pending, Event{
+		Type:   typ,
+		Ref:    ref,
+		Index:  index,
+		Tenant: binocular.tenant,
+	})
+}
+
+// unlock releases the write lock and dispatches the events and eviction callbacks queued while holding it.
+// The first caller dispatches the queued deliveries of all changes without holding any lock, other callers
+// wait until theirs have been delivered unless they are made by the dispatching goroutine from a hook
+// or eviction callback.
+func (binocular *Binocular) unlock() {
+	if len(binocular.pending) == 0 && len(binocular.evicted) == 0 {
+		binocular.mut.Unlock()
+		return
+	}
+	bus := binocular.events
+	d := &delivery{
+		events:  binocular.pending,
+		evicted: binocular.evicted,
+		onEvict: binocular.onEvict,
+		done:    make(chan struct{}),
+	}
+	binocular.pending, binocular.evicted = nil, nil
+	id := goroutineID()
+	bus.queueMut.Lock()
+	bus.queue = append(bus.queue, d)
+	dispatcher, reentrant := bus.dispatcher == 0, bus.dispatcher == id
+	if dispatcher {
+		bus.dispatcher = id
+	}
+	bus.queueMut.Unlock()
+	binocular.mut.Unlock()
+	if dispatcher {
+		bus.drain()
+		return
+	}
+	if !reentrant {
+		<-d.done
+	}
+}
+
+// drain delivers the queued deliveries until the queue is empty.
+func (bus *eventBus) drain() {
+	for {
+		bus.queueMut.Lock()
+		if len(bus.queue) == 0 {
+			bus.dispatcher = 0
+			bus.queueMut.Unlock()
+			return
+		}
+		d := bus.queue[0]
+		bus.queue[0] = nil
+		bus.queue = bus.queue[1:]
+		bus.queueMut.Unlock()
+		bus.dispatch(d.events)
+		for _, e := range d.evicted {
+			d.onEvict(e.id, e.data)
+		}
+		close(d.done)
+	}
+}
+
+// goroutineID returns the id of the calling goroutine parsed from the header of its stack trace.
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	id, _ := strconv.ParseUint(string(header), 10, 64)
+	return id
+}
+
+// Subscription receives the events of a Binocular instance on its channel C until it is closed.
+type Subscription struct {
+	// C receives the events and is closed by Close.
+	C <-chan Event
+
+	c       chan Event
+	policy  BackpressurePolicy
+	mut     sync.RWMutex
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+	bus     *eventBus
+}
+
+// Subscribe returns a Subscription receiving the events of the Binocular instance with a buffer of the given size.
+// The policy determines what happens when the subscriber does not keep up. Close the Subscription when done.
+func (binocular *Binocular) Subscribe(size int, policy BackpressurePolicy) *Subscription {
+	if size < 0 {
+		size = 0
+	}
+	c := make(chan Event, size)
+	sub := &Subscription{
+		C:      c,
+		c:      c,
+		policy: policy,
+		done:   make(chan struct{}),
+		bus:    binocular.events,
+	}
+	binocular.events.mut.Lock()
+	defer binocular.events.mut.Unlock()
+	if binocular.events.subs == nil {
+		binocular.events.subs = make(map[*Subscription]struct{})
+	}
+	binocular.events.subs[sub] = struct{}{}
+	return sub
+}
+
+// Dropped returns the number of events discarded because the buffer was full.
+func (sub *Subscription) Dropped() uint64 {
+	return sub.dropped.Load()
+}
+
+// Close stops the delivery of events and closes C. A change blocked by the Subscription continues.
+func (sub *Subscription) Close() {
+	sub.once.Do(func() {
+		close(sub.done)
+		sub.bus.mut.Lock()
+		delete(sub.bus.subs, sub)
+		sub.bus.mut.Unlock()
+		sub.mut.Lock()
+		defer sub.mut.Unlock()
+		close(sub.c)
+	})
+}
+
+// send delivers the event according to the BackpressurePolicy.
+func (sub *Subscription) send(event Event) {
+	sub.mut.RLock()
+	defer sub.mut.RUnlock()
+	select {
+	case <-sub.done:
+		return
+	default:
+	}
+	switch sub.policy {
+	case DropNewest:
+		select {
+		case sub.c <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	case DropOldest:
+		if cap(sub.c) == 0 {
+			select {
+			case sub.c <- event:
+			default:
+				sub.dropped.Add(1)
+			}
+			return
+		}
+		for {
+			select {
+			case sub.c <- event:
+				return
+			default:
+			}
+			select {
+			case <-sub.c:
+				sub.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case sub.c <- event:
+		case <-sub.done:
+		}
+	}
+}
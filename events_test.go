@@ -0,0 +1,215 @@
+package binocular
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type eventTestDoc struct {
+	Title string `binocular:"title"`
+}
+
+func TestWithHook(t *testing.T) {
+	var mut sync.Mutex
+	events := make([]Event, 0)
+	var b *Binocular
+	b = New(WithHook(func(event Event) {
+		// hooks may use the instance
+		b.Get(event.Ref)
+		mut.Lock()
+		defer mut.Unlock()
+		events = append(events, event)
+	}))
+	if err := b.AddWithID("1", eventTestDoc{"rocket"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.Upsert("1", eventTestDoc{"anvil"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.Remove("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.DropIndex("title"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.Tenant("acme").AddWithID("2", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	batch := b.NewBatch()
+	batch.AddWithID("3", "rocket")
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.DropTenant("acme"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []Event{
+		{Type: IndexCreated, Index: "title"},
+		{Type: DocumentAdded, Ref: "1"},
+		{Type: DocumentUpdated, Ref: "1"},
+		{Type: DocumentRemoved, Ref: "1"},
+		{Type: IndexDropped, Index: "title"},
+		{Type: DocumentAdded, Ref: "2", Tenant: "acme"},
+		{Type: DocumentAdded, Ref: "3"},
+		{Type: TenantDropped, Tenant: "acme"},
+	}
+	mut.Lock()
+	defer mut.Unlock()
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %v, got %v", expected, events)
+	}
+}
+
+func TestWithHook_Write(t *testing.T) {
+	var mut sync.Mutex
+	refs := make([]string, 0)
+	var b *Binocular
+	b = New(WithHook(func(event Event) {
+		mut.Lock()
+		refs = append(refs, event.Tenant+event.Ref)
+		mut.Unlock()
+		if event.Type != DocumentAdded {
+			return
+		}
+		// hooks may change the instance and its tenants
+		switch event.Ref {
+		case "1":
+			b.AddWithID("2", "rocket fuel")
+		case "2":
+			b.Tenant("acme").AddWithID("3", "rocket skates")
+		}
+	}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := b.AddWithID("1", "rocket"); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a hook changing the instance should not deadlock")
+	}
+	if _, err := b.Get("2"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	mut.Lock()
+	defer mut.Unlock()
+	if !reflect.DeepEqual(refs, []string{"1", "2", "acme3"}) {
+		t.Errorf("wrong events: %v", refs)
+	}
+}
+
+func TestWithHook_WaitForDelivery(t *testing.T) {
+	var mut sync.Mutex
+	refs := make([]string, 0)
+	release := make(chan struct{})
+	b := New(WithHook(func(event Event) {
+		if event.Ref == "1" {
+			<-release
+		}
+		mut.Lock()
+		defer mut.Unlock()
+		refs = append(refs, event.Ref)
+	}))
+	go b.AddWithID("1", "rocket")
+	time.Sleep(10 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.AddWithID("2", "rocket")
+	}()
+	select {
+	case <-done:
+		t.Fatal("change should wait until its events have been dispatched")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	mut.Lock()
+	defer mut.Unlock()
+	if !reflect.DeepEqual(refs, []string{"1", "2"}) {
+		t.Errorf("wrong events: %v", refs)
+	}
+}
+
+func TestBinocular_Subscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  BackpressurePolicy
+		refs    []string
+		dropped uint64
+	}{
+		{"drop newest", DropNewest, []string{"1", "2"}, 2},
+		{"drop oldest", DropOldest, []string{"3", "4"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			sub := b.Subscribe(2, tt.policy)
+			for _, id := range []string{"1", "2", "3", "4"} {
+				if err := b.AddWithID(id, "rocket"); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			sub.Close()
+			refs := make([]string, 0)
+			for event := range sub.C {
+				refs = append(refs, event.Ref)
+			}
+			if !reflect.DeepEqual(refs, tt.refs) {
+				t.Errorf("expected %v, got %v", tt.refs, refs)
+			}
+			if sub.Dropped() != tt.dropped {
+				t.Errorf("expected %d dropped events, got %d", tt.dropped, sub.Dropped())
+			}
+		})
+	}
+}
+
+func TestBinocular_Subscribe_BlockOnFull(t *testing.T) {
+	b := New()
+	sub := b.Subscribe(1, BlockOnFull)
+	if err := b.AddWithID("1", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.AddWithID("2", "rocket")
+	}()
+	select {
+	case <-done:
+		t.Fatal("change should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if event := <-sub.C; event.Ref != "1" {
+		t.Errorf("wrong event: %v", event)
+	}
+	if event := <-sub.C; event.Ref != "2" {
+		t.Errorf("wrong event: %v", event)
+	}
+	<-done
+
+	go b.AddWithID("3", "rocket")
+	go b.AddWithID("4", "rocket")
+	time.Sleep(50 * time.Millisecond)
+	sub.Close()
+	if err := b.AddWithID("5", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestEventType_String(t *testing.T) {
+	if DocumentAdded.String() != "document_added" || IndexDropped.String() != "index_dropped" ||
+		TenantDropped.String() != "tenant_dropped" {
+		t.Error("wrong string")
+	}
+	if EventType(42).String() != "unknown" {
+		t.Error("wrong string for unknown type")
+	}
+}
@@ -0,0 +1,215 @@
+package binocular
+
+import (
+	"sort"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// Clause is the part of a Query a word belongs to.
+type Clause int
+
+const (
+	// ClauseAll is a word of Query.All.
+	ClauseAll Clause = iota
+	// ClauseAny is a word of Query.Any.
+	ClauseAny
+	// ClauseNone is a word of Query.None.
+	ClauseNone
+)
+
+func (clause Clause) String() string {
+	switch clause {
+	case ClauseAll:
+		return "all"
+	case ClauseAny:
+		return "any"
+	case ClauseNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// Explanation describes why a reference matches a Query or why it does not.
+type Explanation struct {
+	Ref   string
+	Index string
+	// Matched reports whether the reference is found by the Query with the Filters.
+	Matched bool
+	// Score is the sum of the scores of all matches of All and Any words.
+	// It equals the score of the Hit if the reference is found.
+	Score float64
+	// Words holds an explanation for every word of the Query.
+	Words []WordExplanation
+	// Filters holds an explanation for every Filter.
+	Filters []FilterExplanation
+}
+
+// WordExplanation describes how a word of a Query matched a reference.
+type WordExplanation struct {
+	Clause Clause
+	// Word is the word as given in the Query and Term the word after it has been analyzed by the Index.
+	Word string
+	Term string
+	// Matches holds the terms of the Index which match the word and are stored for the reference.
+	// It is empty if the word does not match the reference.
+	Matches []TermMatch
+}
+
+// TermMatch is a term of an Index matching a word of a Query with its score breakdown.
+// The score is TermFrequency * IDF and IDF is ln(1 + Docs / DocFreq), where Docs is the number of documents of the Index.
+type TermMatch struct {
+	Term string
+	// Distance is the Levenshtein distance computed by the fuzzy matcher, 0 for exact matches.
+	Distance      int
+	TermFrequency int
+	DocFreq       int
+	Docs          int
+	IDF           float64
+	Score         float64
+}
+
+// FilterExplanation describes whether a Filter matched a reference.
+type FilterExplanation struct {
+	Filter
+	// Terms are the terms the value has been analyzed to by the Index of the Filter.
+	Terms   []string
+	Matched bool
+}
+
+// Explain describes why the reference with the given id is found by the Query on the given index and the Filters
+// or why it is not. ErrIndexNotFound is returned if the given index or the index of a Filter does not exist
+// and ErrRefNotFound if the reference does not exist.
+func (binocular *Binocular) Explain(query Query, index string, ref string, filters ...Filter) (*Explanation, error) {
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
+	i, ok := binocular.indices[index]
+	if !ok {
+		return nil, ErrIndexNotFound
+	}
+	if _, ok := binocular.docs[ref]; !ok {
+		return nil, ErrRefNotFound
+	}
+	explanation := &Explanation{
+		Ref:   ref,
+		Index: index,
+		Words: i.explain(query, ref),
+	}
+
+	all, anyWords, anyMatched, none := true, false, false, false
+	for _, word := range explanation.Words {
+		matched := len(word.Matches) > 0
+		switch word.Clause {
+		case ClauseAll:
+			all = all && matched
+		case ClauseAny:
+			anyWords = true
+			anyMatched = anyMatched || matched
+		case ClauseNone:
+			none = none || matched
+			continue
+		}
+		for _, match := range word.Matches {
+			explanation.Score += match.Score
+		}
+	}
+	explanation.Matched = (len(query.All) > 0 || anyWords) && all && (!anyWords || anyMatched) && !none
+
+	for _, f := range filters {
+		fi, ok := binocular.indices[f.Index]
+		if !ok {
+			return nil, ErrIndexNotFound
+		}
+		fe := fi.explainFilter(f, ref)
+		explanation.Matched = explanation.Matched && fe.Matched
+		explanation.Filters = append(explanation.Filters, fe)
+	}
+	return explanation, nil
+}
+
+// returns an explanation for every word of the Query with the matches stored for the reference.
+func (index *Index) explain(query Query, ref string) []WordExplanation {
+	clauses := []struct {
+		clause Clause
+		words  []string
+	}{
+		{ClauseAll, query.All},
+		{ClauseAny, query.Any},
+		{ClauseNone, query.None},
+	}
+	num, found := index.refs.lookup(ref)
+	index.rLock()
+	defer index.mut.RUnlock()
+	docs := int(index.docs.GetCardinality())
+	words := make([]WordExplanation, 0, len(query.All)+len(query.Any)+len(query.None))
+	for _, c := range clauses {
+		for _, word := range c.words {
+			we := WordExplanation{
+				Clause:  c.clause,
+				Word:    word,
+				Term:    index.normalize(word),
+				Matches: []TermMatch{},
+			}
+			if found {
+				we.Matches = index.explainMatches(we.Term, query.Distance, num, docs)
+			}
+			words = append(words, we)
+		}
+	}
+	return words
+}
+
+// returns the terms matching the normalized word within the distance which are stored for the document number.
+// The caller must hold the read lock.
+func (index *Index) explainMatches(word string, distance int, num uint32, docs int) []TermMatch {
+	matches := []TermMatch{}
+	add := func(term string, d int) {
+		postings := index.data[term]
+		if !postings.Contains(num) {
+			return
+		}
+		idf := index.idf(float64(docs), postings)
+		tf := index.tf(term, num)
+		matches = append(matches, TermMatch{
+			Term:          term,
+			Distance:      d,
+			TermFrequency: int(tf),
+			DocFreq:       int(postings.GetCardinality()),
+			Docs:          docs,
+			IDF:           idf,
+			Score:         float64(tf) * idf,
+		})
+	}
+	if distance <= 0 {
+		if _, ok := index.data[word]; ok {
+			add(word, 0)
+		}
+		return matches
+	}
+	for term := range index.data {
+		d := fuzzy.RankMatch(word, term)
+		if d > -1 && d <= distance {
+			add(term, d)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Term < matches[j].Term
+	})
+	return matches
+}
+
+// returns whether the reference matches the Filter.
+func (index *Index) explainFilter(f Filter, ref string) FilterExplanation {
+	fe := FilterExplanation{
+		Filter: f,
+		Terms:  index.analyze(f.Value),
+	}
+	num, ok := index.refs.lookup(ref)
+	if !ok {
+		return fe
+	}
+	bitmap, _ := index.filter(f.Value)
+	fe.Matched = bitmap.Contains(num)
+	return fe
+}
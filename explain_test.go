@@ -0,0 +1,125 @@
+package binocular
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newExplainTestBinocular(t *testing.T) *Binocular {
+	type doc struct {
+		Text   string `binocular:"default"`
+		Status string `binocular:"status"`
+	}
+	b := New(WithDefaultIndex(DefaultIndex, WithFrequencies()), WithIndex("status", WithKeyword()))
+	docs := []struct {
+		id     string
+		text   string
+		status string
+	}{
+		{"1", "rocket rocket launch", "published"},
+		{"2", "rocket fuel", "draft"},
+		{"3", "anvil", "published"},
+	}
+	for _, d := range docs {
+		if err := b.AddWithID(d.id, doc{d.text, d.status}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	return b
+}
+
+func TestBinocular_Explain(t *testing.T) {
+	b := newExplainTestBinocular(t)
+	query := Query{All: []string{"Rockt"}, Any: []string{"launch", "fuel"}, Distance: 1}
+	explanation, err := b.Explain(query, DefaultIndex, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !explanation.Matched {
+		t.Error("ref should match")
+	}
+	result, err := b.Query(query, DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, hit := range result.Hits() {
+		if hit.Ref == "1" && hit.Score != explanation.Score {
+			t.Errorf("score should equal the hit score %f, got %f", hit.Score, explanation.Score)
+		}
+	}
+
+	rockets := explanation.Words[0]
+	if rockets.Clause != ClauseAll || rockets.Word != "Rockt" || rockets.Term != "rockt" {
+		t.Errorf("wrong word: %+v", rockets)
+	}
+	expected := []TermMatch{{
+		Term:          "rocket",
+		Distance:      1,
+		TermFrequency: 2,
+		DocFreq:       2,
+		Docs:          3,
+		IDF:           b.indices[DefaultIndex].idf(3, b.indices[DefaultIndex].data["rocket"]),
+	}}
+	expected[0].Score = 2 * expected[0].IDF
+	if !reflect.DeepEqual(rockets.Matches, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rockets.Matches)
+	}
+	if len(explanation.Words[1].Matches) != 1 || len(explanation.Words[2].Matches) != 0 {
+		t.Errorf("wrong matches for any words: %+v", explanation.Words[1:])
+	}
+}
+
+func TestBinocular_Explain_NotMatched(t *testing.T) {
+	b := newExplainTestBinocular(t)
+	tests := []struct {
+		name    string
+		query   Query
+		ref     string
+		filters []Filter
+		matched bool
+	}{
+		{"all matched", Query{All: []string{"rocket"}}, "2", nil, true},
+		{"all missing", Query{All: []string{"rocket", "launch"}}, "2", nil, false},
+		{"any missing", Query{Any: []string{"launch"}}, "2", nil, false},
+		{"none matched", Query{All: []string{"rocket"}, None: []string{"fuel"}}, "2", nil, false},
+		{"empty query", Query{None: []string{"anvil"}}, "2", nil, false},
+		{"filter matched", Query{All: []string{"rocket"}}, "1", []Filter{{"status", "published"}}, true},
+		{"filter missing", Query{All: []string{"rocket"}}, "2", []Filter{{"status", "published"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation, err := b.Explain(tt.query, DefaultIndex, tt.ref, tt.filters...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if explanation.Matched != tt.matched {
+				t.Errorf("expected matched to be %t: %+v", tt.matched, explanation)
+			}
+			result, err := b.Query(tt.query, DefaultIndex, tt.filters...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			found := false
+			for _, ref := range result.Refs() {
+				found = found || ref == tt.ref
+			}
+			if found != tt.matched {
+				t.Errorf("explanation should agree with the search")
+			}
+		})
+	}
+}
+
+func TestBinocular_Explain_Errors(t *testing.T) {
+	b := newExplainTestBinocular(t)
+	query := Query{All: []string{"rocket"}}
+	if _, err := b.Explain(query, "unknown", "1"); err != ErrIndexNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := b.Explain(query, DefaultIndex, "4"); err != ErrRefNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := b.Explain(query, DefaultIndex, "1", Filter{"unknown", "x"}); err != ErrIndexNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+}
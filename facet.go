@@ -0,0 +1,154 @@
+package binocular
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ErrInvalidInterval indicates that a histogram interval is not a positive number.
+var ErrInvalidInterval = errors.New("invalid interval")
+
+// TermBucket holds the number of found references having the value in a faceted Index.
+type TermBucket struct {
+	Value string
+	Count int
+}
+
+// HistogramBucket holds the number of found references with a numeric value in [Key, Key+interval).
+type HistogramBucket struct {
+	Key   float64
+	Count int
+}
+
+// Range is a numeric range from From (inclusive) to To (exclusive).
+// Use math.Inf to create open ranges.
+type Range struct {
+	From float64
+	To   float64
+}
+
+// RangeBucket holds the number of found references with a numeric value within the Range.
+type RangeBucket struct {
+	Range
+	Count int
+}
+
+// TermFacet counts the values the found references have in the given index, which is usually
+// a keyword index. The buckets are sorted by count and value and limited to size if it is positive.
+// A reference with multiple distinct values is counted once per value.
+// ErrIndexNotFound is returned if the given index does not exist.
+func (searchResult *SearchResult) TermFacet(index string, size int) ([]TermBucket, error) {
+	counts := make(map[string]int)
+	err := searchResult.eachValues(index, func(values []string) {
+		seen := make(map[string]struct{}, len(values))
+		for _, value := range values {
+			if _, ok := seen[value]; ok {
+				continue
+			}
+			seen[value] = struct{}{}
+			counts[value]++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([]TermBucket, 0, len(counts))
+	for value, count := range counts {
+		buckets = append(buckets, TermBucket{Value: value, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].Value < buckets[j].Value
+	})
+	if size > 0 && size < len(buckets) {
+		buckets = buckets[:size]
+	}
+	return buckets, nil
+}
+
+// Histogram counts the numeric values the found references have in the given index in buckets
+// of the given interval. The buckets are sorted by key, empty buckets are omitted and values
+// which are not numbers are ignored.
+// ErrIndexNotFound is returned if the given index does not exist and ErrInvalidInterval if the interval is not positive.
+func (searchResult *SearchResult) Histogram(index string, interval float64) ([]HistogramBucket, error) {
+	if !(interval > 0) || math.IsInf(interval, 1) {
+		return nil, ErrInvalidInterval
+	}
+	counts := make(map[float64]int)
+	err := searchResult.eachValues(index, func(values []string) {
+		for _, n := range parseNumbers(values) {
+			counts[math.Floor(n/interval)*interval]++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([]HistogramBucket, 0, len(counts))
+	for key, count := range counts {
+		buckets = append(buckets, HistogramBucket{Key: key, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Key < buckets[j].Key
+	})
+	return buckets, nil
+}
+
+// Ranges counts the numeric values the found references have in the given index for each Range.
+// The buckets are returned in the order of the given ranges, values which are not numbers are ignored.
+// ErrIndexNotFound is returned if the given index does not exist.
+func (searchResult *SearchResult) Ranges(index string, ranges ...Range) ([]RangeBucket, error) {
+	buckets := make([]RangeBucket, len(ranges))
+	for i, r := range ranges {
+		buckets[i].Range = r
+	}
+	err := searchResult.eachValues(index, func(values []string) {
+		for _, n := range parseNumbers(values) {
+			for i, r := range ranges {
+				if n >= r.From && n < r.To {
+					buckets[i].Count++
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// calls fn with the values every found reference has in the given index.
+func (searchResult *SearchResult) eachValues(index string, fn func(values []string)) error {
+	binocular := searchResult.binocular
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
+	if _, ok := binocular.indices[index]; !ok {
+		return ErrIndexNotFound
+	}
+	for _, ref := range searchResult.refs {
+		doc, ok := binocular.docs[ref]
+		if !ok {
+			continue
+		}
+		if values, ok := doc.recordLocator[index]; ok {
+			fn(values)
+		}
+	}
+	return nil
+}
+
+// parses the values as numbers and skips the ones which are not.
+func parseNumbers(values []string) []float64 {
+	numbers := make([]float64, 0, len(values))
+	for _, value := range values {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(n) {
+			continue
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
@@ -0,0 +1,114 @@
+package binocular
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+type facetTestProduct struct {
+	Name  string  `binocular:"default"`
+	Brand string  `binocular:"brand,keyword"`
+	Price float64 `binocular:"price,numeric"`
+	Stock int     `binocular:"stock,numeric"`
+}
+
+func newFacetTestBinocular(t *testing.T) *Binocular {
+	b := New()
+	products := []facetTestProduct{
+		{"Rocket skates", "Acme", 49.99, 3},
+		{"Rocket sled", "Acme", 120, 0},
+		{"Rocket fuel", "Globex", 15.5, 42},
+		{"Anvil", "Acme", 80, 7},
+	}
+	for _, p := range products {
+		if _, err := b.Add(p); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	return b
+}
+
+func TestBinocular_Add_KeywordAndNumericTags(t *testing.T) {
+	b := newFacetTestBinocular(t)
+	if !b.indices["brand"].keyword || !b.indices["price"].keyword {
+		t.Error("keyword and numeric fields should create keyword indices")
+	}
+	result, err := b.Search("120", "price")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 1 {
+		t.Error("numeric values should be searchable")
+	}
+}
+
+func TestSearchResult_TermFacet(t *testing.T) {
+	b := newFacetTestBinocular(t)
+	result, err := b.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	buckets, err := result.TermFacet("brand", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []TermBucket{{"Acme", 2}, {"Globex", 1}}
+	if !reflect.DeepEqual(buckets, expected) {
+		t.Errorf("expected %v, got %v", expected, buckets)
+	}
+	buckets, err = result.TermFacet("brand", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(buckets) != 1 {
+		t.Error("buckets should be limited to size")
+	}
+	_, err = result.TermFacet("unknown_idx", 0)
+	if err != ErrIndexNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestSearchResult_Histogram(t *testing.T) {
+	b := newFacetTestBinocular(t)
+	result, err := b.Query(Query{Any: []string{"rocket", "anvil"}}, DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	buckets, err := result.Histogram("price", 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []HistogramBucket{{0, 2}, {50, 1}, {100, 1}}
+	if !reflect.DeepEqual(buckets, expected) {
+		t.Errorf("expected %v, got %v", expected, buckets)
+	}
+	_, err = result.Histogram("price", 0)
+	if err != ErrInvalidInterval {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestSearchResult_Ranges(t *testing.T) {
+	b := newFacetTestBinocular(t)
+	result, err := b.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	buckets, err := result.Ranges("stock",
+		Range{math.Inf(-1), 1},
+		Range{1, 10},
+		Range{10, math.Inf(1)},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	counts := []int{buckets[0].Count, buckets[1].Count, buckets[2].Count}
+	if !reflect.DeepEqual(counts, []int{1, 1, 1}) {
+		t.Errorf("wrong counts: %v", counts)
+	}
+	if buckets[1].From != 1 || buckets[1].To != 10 {
+		t.Error("buckets should hold their range")
+	}
+}
@@ -0,0 +1,142 @@
+package binocular
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/RoaringBitmap/roaring"
+)
+
+// maxCachedFilters is the number of filter combinations after which the filter cache is cleared.
+const maxCachedFilters = 1024
+
+// Filter restricts a search to the references having the value in the given index without changing their scores.
+// Filters are meant for keyword indices where the value has to match exactly, values for other indices are
+// analyzed like added data and every resulting term has to match.
+type Filter struct {
+	Index string
+	Value string
+}
+
+// filterCache holds the document numbers of filter combinations together with
+// the versions of the indices they have been computed from.
+type filterCache struct {
+	mut     sync.Mutex
+	entries map[string]cachedFilter
+}
+
+// cachedFilter is a cached filter combination which is stale once one of its indices has changed
+// or has been replaced by another Index of the same name.
+type cachedFilter struct {
+	bitmap  *roaring.Bitmap
+	names   []string
+	indices []*Index
+	version []uint64
+}
+
+func newFilterCache() *filterCache {
+	return &filterCache{
+		entries: make(map[string]cachedFilter),
+	}
+}
+
+// get returns the cached bitmap for the key if its indices are still the current ones and none of them has changed since.
+func (cache *filterCache) get(key string, indices map[string]*Index) (*roaring.Bitmap, bool) {
+	cache.mut.Lock()
+	entry, ok := cache.entries[key]
+	cache.mut.Unlock()
+	if !ok {
+		return nil, false
+	}
+	for i, index := range entry.indices {
+		if indices[entry.names[i]] != index {
+			return nil, false
+		}
+		index.rLock()
+		version := index.version
+		index.mut.RUnlock()
+		if version != entry.version[i] {
+			return nil, false
+		}
+	}
+	return entry.bitmap, true
+}
+
+// put caches the bitmap for the key and clears the cache if it is full.
+func (cache *filterCache) put(key string, entry cachedFilter) {
+	cache.mut.Lock()
+	defer cache.mut.Unlock()
+	if _, ok := cache.entries[key]; !ok && len(cache.entries) >= maxCachedFilters {
+		cache.entries = make(map[string]cachedFilter)
+	}
+	cache.entries[key] = entry
+}
+
+// filter returns the document numbers matching all filters or nil if there are none.
+// The returned bitmap is shared and must not be modified. The caller must hold the read lock.
+// ErrIndexNotFound is returned if the index of a filter does not exist.
+func (binocular *Binocular) filter(filters []Filter) (*roaring.Bitmap, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	key := filterKey(filters)
+	if bitmap, ok := binocular.filters.get(key, binocular.indices); ok {
+		return bitmap, nil
+	}
+	entry := cachedFilter{
+		names:   make([]string, len(filters)),
+		indices: make([]*Index, len(filters)),
+		version: make([]uint64, len(filters)),
+	}
+	for i, f := range filters {
+		index, ok := binocular.indices[f.Index]
+		if !ok {
+			return nil, ErrIndexNotFound
+		}
+		bitmap, version := index.filter(f.Value)
+		entry.names[i] = f.Index
+		entry.indices[i] = index
+		entry.version[i] = version
+		if entry.bitmap == nil {
+			entry.bitmap = bitmap
+			continue
+		}
+		entry.bitmap.And(bitmap)
+	}
+	binocular.filters.put(key, entry)
+	return entry.bitmap, nil
+}
+
+// filter returns a new bitmap with the document numbers having all terms of the value and the current version.
+func (index *Index) filter(value string) (*roaring.Bitmap, uint64) {
+	terms := index.analyze(value)
+	index.rLock()
+	defer index.mut.RUnlock()
+	if len(terms) == 0 {
+		return roaring.New(), index.version
+	}
+	var result *roaring.Bitmap
+	for _, term := range terms {
+		bitmap, ok := index.data[term]
+		if !ok {
+			return roaring.New(), index.version
+		}
+		if result == nil {
+			result = bitmap.Clone()
+			continue
+		}
+		result.And(bitmap)
+	}
+	return result, index.version
+}
+
+// returns a key which is equal for the same filters in any order.
+func filterKey(filters []Filter) string {
+	parts := make([]string, len(filters))
+	for i, f := range filters {
+		parts[i] = f.Index + "\x00" + f.Value
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\x01")
+}
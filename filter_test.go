@@ -0,0 +1,181 @@
+package binocular
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+)
+
+type filterTestArticle struct {
+	Title  string `binocular:"default"`
+	Status string `binocular:"status,keyword"`
+	Tenant string `binocular:"tenant,keyword"`
+}
+
+func newFilterTestBinocular(t *testing.T) *Binocular {
+	b := New()
+	articles := map[string]filterTestArticle{
+		"a": {"rocket science", "published", "42"},
+		"b": {"rocket launch", "draft", "42"},
+		"c": {"rocket fuel", "published", "7"},
+		"d": {"anvil", "published", "42"},
+	}
+	for id, a := range articles {
+		if err := b.AddWithID(id, a); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	return b
+}
+
+func TestBinocular_Search_Filter(t *testing.T) {
+	b := newFilterTestBinocular(t)
+	tests := []struct {
+		name    string
+		filters []Filter
+		refs    []string
+		err     error
+	}{
+		{"no filter", nil, []string{"a", "b", "c"}, nil},
+		{"single filter", []Filter{{"status", "published"}}, []string{"a", "c"}, nil},
+		{"multiple filters", []Filter{{"status", "published"}, {"tenant", "42"}}, []string{"a"}, nil},
+		{"multiple filters reversed", []Filter{{"tenant", "42"}, {"status", "published"}}, []string{"a"}, nil},
+		{"no match", []Filter{{"status", "archived"}}, []string{}, nil},
+		{"unknown index", []Filter{{"unknown_idx", "x"}}, nil, ErrIndexNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := b.Search("rocket", DefaultIndex, tt.filters...)
+			if err != tt.err {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			refs := make([]string, 0)
+			for _, hit := range result.Hits() {
+				refs = append(refs, hit.Ref)
+			}
+			sort.Strings(refs)
+			if !reflect.DeepEqual(refs, tt.refs) {
+				t.Errorf("expected %v, got %v", tt.refs, refs)
+			}
+		})
+	}
+}
+
+func TestBinocular_Search_FilterDoesNotChangeScores(t *testing.T) {
+	b := newFilterTestBinocular(t)
+	all, err := b.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	filtered, err := b.Search("rocket", DefaultIndex, Filter{"status", "published"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	scores := make(map[string]float64)
+	for _, hit := range all.Hits() {
+		scores[hit.Ref] = hit.Score
+	}
+	for _, hit := range filtered.Hits() {
+		if hit.Score != scores[hit.Ref] {
+			t.Errorf("score of %s changed from %f to %f", hit.Ref, scores[hit.Ref], hit.Score)
+		}
+	}
+}
+
+func TestBinocular_Search_FilterCache(t *testing.T) {
+	b := newFilterTestBinocular(t)
+	filters := []Filter{{"status", "published"}, {"tenant", "42"}}
+	b.mut.RLock()
+	first, _ := b.filter(filters)
+	second, _ := b.filter(filters)
+	b.mut.RUnlock()
+	if first != second {
+		t.Error("repeated filters should be cached")
+	}
+
+	err := b.AddWithID("e", filterTestArticle{"rocket sled", "published", "42"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := b.Search("rocket", DefaultIndex, filters...)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 2 {
+		t.Errorf("cached filter should be invalidated on changes, got %v", result.Refs())
+	}
+
+	if err := b.Remove("a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err = b.Search("rocket", DefaultIndex, filters...)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(result.Refs(), []string{"e"}) {
+		t.Errorf("cached filter should be invalidated on removal, got %v", result.Refs())
+	}
+}
+
+func TestFilterKey(t *testing.T) {
+	a := filterKey([]Filter{{"status", "published"}, {"tenant", "42"}})
+	b := filterKey([]Filter{{"tenant", "42"}, {"status", "published"}})
+	if a != b {
+		t.Error("keys should not depend on the order of filters")
+	}
+	if filterKey([]Filter{{"a", "bc"}}) == filterKey([]Filter{{"ab", "c"}}) {
+		t.Error("keys should separate index and value")
+	}
+}
+
+func TestBinocular_Search_FilterCacheAfterRestore(t *testing.T) {
+	b := newFilterTestBinocular(t)
+	filter := Filter{"status", "published"}
+	if _, err := b.Search("rocket", DefaultIndex, filter); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gob.Register(filterTestArticle{})
+	other := New()
+	_ = other.AddWithID("x", filterTestArticle{"rocket sled", "draft", "42"})
+	_ = other.AddWithID("a", filterTestArticle{"rocket science", "published", "42"})
+	var buf bytes.Buffer
+	if err := other.SnapshotContext(context.Background(), &buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.RestoreContext(context.Background(), &buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := b.Search("rocket", DefaultIndex, filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(result.Refs(), []string{"a"}) {
+		t.Errorf("cached filter should not survive a restore, got %v", result.Refs())
+	}
+}
+
+func TestFilterCache_ReplacedIndex(t *testing.T) {
+	cache := newFilterCache()
+	index := NewIndex()
+	cache.put("key", cachedFilter{
+		bitmap:  roaring.New(),
+		names:   []string{"status"},
+		indices: []*Index{index},
+		version: []uint64{index.version},
+	})
+	if _, ok := cache.get("key", map[string]*Index{"status": index}); !ok {
+		t.Error("filter should be cached")
+	}
+	if _, ok := cache.get("key", map[string]*Index{"status": NewIndex()}); ok {
+		t.Error("filter of a replaced index should be stale")
+	}
+}
@@ -0,0 +1,446 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: binocular.proto
+
+package binocularpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Binocular_Add_FullMethodName          = "/binocular.v1.Binocular/Add"
+	Binocular_AddWithID_FullMethodName    = "/binocular.v1.Binocular/AddWithID"
+	Binocular_Get_FullMethodName          = "/binocular.v1.Binocular/Get"
+	Binocular_Remove_FullMethodName       = "/binocular.v1.Binocular/Remove"
+	Binocular_Search_FullMethodName       = "/binocular.v1.Binocular/Search"
+	Binocular_FuzzySearch_FullMethodName  = "/binocular.v1.Binocular/FuzzySearch"
+	Binocular_BulkAdd_FullMethodName      = "/binocular.v1.Binocular/BulkAdd"
+	Binocular_StreamSearch_FullMethodName = "/binocular.v1.Binocular/StreamSearch"
+)
+
+// BinocularClient is the client API for Binocular service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BinocularClient interface {
+	// Add adds a document with a generated id.
+	Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*AddResponse, error)
+	// AddWithID adds a document with the given id.
+	AddWithID(ctx context.Context, in *AddWithIDRequest, opts ...grpc.CallOption) (*AddWithIDResponse, error)
+	// Get returns the document with the given id.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// Remove removes the document with the given id.
+	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error)
+	// Search searches an index for a word.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// FuzzySearch searches an index for a word within a Levenshtein distance.
+	FuzzySearch(ctx context.Context, in *FuzzySearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// BulkAdd adds all streamed documents in batches.
+	BulkAdd(ctx context.Context, opts ...grpc.CallOption) (Binocular_BulkAddClient, error)
+	// StreamSearch streams the hits of a search, the most relevant first.
+	StreamSearch(ctx context.Context, in *FuzzySearchRequest, opts ...grpc.CallOption) (Binocular_StreamSearchClient, error)
+}
+
+type binocularClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBinocularClient(cc grpc.ClientConnInterface) BinocularClient {
+	return &binocularClient{cc}
+}
+
+func (c *binocularClient) Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*AddResponse, error) {
+	out := new(AddResponse)
+	err := c.cc.Invoke(ctx, Binocular_Add_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binocularClient) AddWithID(ctx context.Context, in *AddWithIDRequest, opts ...grpc.CallOption) (*AddWithIDResponse, error) {
+	out := new(AddWithIDResponse)
+	err := c.cc.Invoke(ctx, Binocular_AddWithID_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binocularClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, Binocular_Get_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binocularClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error) {
+	out := new(RemoveResponse)
+	err := c.cc.Invoke(ctx, Binocular_Remove_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binocularClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, Binocular_Search_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binocularClient) FuzzySearch(ctx context.Context, in *FuzzySearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, Binocular_FuzzySearch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binocularClient) BulkAdd(ctx context.Context, opts ...grpc.CallOption) (Binocular_BulkAddClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Binocular_ServiceDesc.Streams[0], Binocular_BulkAdd_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &binocularBulkAddClient{stream}
+	return x, nil
+}
+
+type Binocular_BulkAddClient interface {
+	Send(*AddWithIDRequest) error
+	CloseAndRecv() (*BulkAddResponse, error)
+	grpc.ClientStream
+}
+
+type binocularBulkAddClient struct {
+	grpc.ClientStream
+}
+
+func (x *binocularBulkAddClient) Send(m *AddWithIDRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *binocularBulkAddClient) CloseAndRecv() (*BulkAddResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BulkAddResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *binocularClient) StreamSearch(ctx context.Context, in *FuzzySearchRequest, opts ...grpc.CallOption) (Binocular_StreamSearchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Binocular_ServiceDesc.Streams[1], Binocular_StreamSearch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &binocularStreamSearchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Binocular_StreamSearchClient interface {
+	Recv() (*Hit, error)
+	grpc.ClientStream
+}
+
+type binocularStreamSearchClient struct {
+	grpc.ClientStream
+}
+
+func (x *binocularStreamSearchClient) Recv() (*Hit, error) {
+	m := new(Hit)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BinocularServer is the server API for Binocular service.
+// All implementations must embed UnimplementedBinocularServer
+// for forward compatibility
+type BinocularServer interface {
+	// Add adds a document with a generated id.
+	Add(context.Context, *AddRequest) (*AddResponse, error)
+	// AddWithID adds a document with the given id.
+	AddWithID(context.Context, *AddWithIDRequest) (*AddWithIDResponse, error)
+	// Get returns the document with the given id.
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// Remove removes the document with the given id.
+	Remove(context.Context, *RemoveRequest) (*RemoveResponse, error)
+	// Search searches an index for a word.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// FuzzySearch searches an index for a word within a Levenshtein distance.
+	FuzzySearch(context.Context, *FuzzySearchRequest) (*SearchResponse, error)
+	// BulkAdd adds all streamed documents in batches.
+	BulkAdd(Binocular_BulkAddServer) error
+	// StreamSearch streams the hits of a search, the most relevant first.
+	StreamSearch(*FuzzySearchRequest, Binocular_StreamSearchServer) error
+	mustEmbedUnimplementedBinocularServer()
+}
+
+// UnimplementedBinocularServer must be embedded to have forward compatible implementations.
+type UnimplementedBinocularServer struct {
+}
+
+func (UnimplementedBinocularServer) Add(context.Context, *AddRequest) (*AddResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
+}
+func (UnimplementedBinocularServer) AddWithID(context.Context, *AddWithIDRequest) (*AddWithIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddWithID not implemented")
+}
+func (UnimplementedBinocularServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedBinocularServer) Remove(context.Context, *RemoveRequest) (*RemoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Remove not implemented")
+}
+func (UnimplementedBinocularServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedBinocularServer) FuzzySearch(context.Context, *FuzzySearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FuzzySearch not implemented")
+}
+func (UnimplementedBinocularServer) BulkAdd(Binocular_BulkAddServer) error {
+	return status.Errorf(codes.Unimplemented, "method BulkAdd not implemented")
+}
+func (UnimplementedBinocularServer) StreamSearch(*FuzzySearchRequest, Binocular_StreamSearchServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSearch not implemented")
+}
+func (UnimplementedBinocularServer) mustEmbedUnimplementedBinocularServer() {}
+
+// UnsafeBinocularServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BinocularServer will
+// result in compilation errors.
+type UnsafeBinocularServer interface {
+	mustEmbedUnimplementedBinocularServer()
+}
+
+func RegisterBinocularServer(s grpc.ServiceRegistrar, srv BinocularServer) {
+	s.RegisterService(&Binocular_ServiceDesc, srv)
+}
+
+func _Binocular_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinocularServer).Add(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Binocular_Add_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinocularServer).Add(ctx, req.(*AddRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Binocular_AddWithID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddWithIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinocularServer).AddWithID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Binocular_AddWithID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinocularServer).AddWithID(ctx, req.(*AddWithIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Binocular_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinocularServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Binocular_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinocularServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Binocular_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinocularServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Binocular_Remove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinocularServer).Remove(ctx, req.(*RemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Binocular_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinocularServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Binocular_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinocularServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Binocular_FuzzySearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FuzzySearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinocularServer).FuzzySearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Binocular_FuzzySearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinocularServer).FuzzySearch(ctx, req.(*FuzzySearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Binocular_BulkAdd_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BinocularServer).BulkAdd(&binocularBulkAddServer{stream})
+}
+
+type Binocular_BulkAddServer interface {
+	SendAndClose(*BulkAddResponse) error
+	Recv() (*AddWithIDRequest, error)
+	grpc.ServerStream
+}
+
+type binocularBulkAddServer struct {
+	grpc.ServerStream
+}
+
+func (x *binocularBulkAddServer) SendAndClose(m *BulkAddResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *binocularBulkAddServer) Recv() (*AddWithIDRequest, error) {
+	m := new(AddWithIDRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Binocular_StreamSearch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FuzzySearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BinocularServer).StreamSearch(m, &binocularStreamSearchServer{stream})
+}
+
+type Binocular_StreamSearchServer interface {
+	Send(*Hit) error
+	grpc.ServerStream
+}
+
+type binocularStreamSearchServer struct {
+	grpc.ServerStream
+}
+
+func (x *binocularStreamSearchServer) Send(m *Hit) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Binocular_ServiceDesc is the grpc.ServiceDesc for Binocular service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Binocular_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "binocular.v1.Binocular",
+	HandlerType: (*BinocularServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Add",
+			Handler:    _Binocular_Add_Handler,
+		},
+		{
+			MethodName: "AddWithID",
+			Handler:    _Binocular_AddWithID_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _Binocular_Get_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _Binocular_Remove_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _Binocular_Search_Handler,
+		},
+		{
+			MethodName: "FuzzySearch",
+			Handler:    _Binocular_FuzzySearch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BulkAdd",
+			Handler:       _Binocular_BulkAdd_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamSearch",
+			Handler:       _Binocular_StreamSearch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "binocular.proto",
+}
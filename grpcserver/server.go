@@ -0,0 +1,253 @@
+// Package grpcserver exposes a Binocular instance over gRPC.
+//
+// The service is defined in proto/binocular.proto, binocularpb holds the generated code including the client.
+// Documents are exchanged as JSON and stored as json.RawMessage so field mappings of the Binocular instance are applied.
+package grpcserver
+
+//go:generate buf generate proto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mycreepy/go-binocular"
+	"github.com/mycreepy/go-binocular/grpcserver/binocularpb"
+)
+
+// DefaultBulkSize is the default number of documents BulkAdd commits at once.
+const DefaultBulkSize = 1000
+
+// Server implements binocularpb.BinocularServer for a Binocular instance.
+type Server struct {
+	binocularpb.UnimplementedBinocularServer
+
+	binocular *binocular.Binocular
+	bulkSize  int
+}
+
+// Option can alter the behavior of a Server.
+type Option func(server *Server)
+
+// WithBulkSize sets the number of documents BulkAdd commits at once.
+func WithBulkSize(n int) Option {
+	return func(server *Server) {
+		server.bulkSize = n
+	}
+}
+
+// New creates a new Server for the given Binocular instance with the given Options.
+func New(b *binocular.Binocular, options ...Option) *Server {
+	server := &Server{
+		binocular: b,
+		bulkSize:  DefaultBulkSize,
+	}
+	for _, opt := range options {
+		opt(server)
+	}
+	return server
+}
+
+// Register creates a new Server for the given Binocular instance and registers it with the gRPC server.
+func Register(s grpc.ServiceRegistrar, b *binocular.Binocular, options ...Option) {
+	binocularpb.RegisterBinocularServer(s, New(b, options...))
+}
+
+// Add adds a document with a generated id.
+func (server *Server) Add(_ context.Context, req *binocularpb.AddRequest) (*binocularpb.AddResponse, error) {
+	doc, err := readDocument(req.GetDocument())
+	if err != nil {
+		return nil, err
+	}
+	id, err := server.binocular.Add(doc)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &binocularpb.AddResponse{Id: id}, nil
+}
+
+// AddWithID adds a document with the given id.
+func (server *Server) AddWithID(_ context.Context, req *binocularpb.AddWithIDRequest) (*binocularpb.AddWithIDResponse, error) {
+	doc, err := readDocument(req.GetDocument())
+	if err != nil {
+		return nil, err
+	}
+	if err := server.binocular.AddWithID(req.GetId(), doc); err != nil {
+		return nil, toStatus(err)
+	}
+	return &binocularpb.AddWithIDResponse{}, nil
+}
+
+// Get returns the document with the given id.
+func (server *Server) Get(_ context.Context, req *binocularpb.GetRequest) (*binocularpb.GetResponse, error) {
+	data, err := server.binocular.Get(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	doc, err := marshalDocument(data)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &binocularpb.GetResponse{Document: doc}, nil
+}
+
+// Remove removes the document with the given id.
+func (server *Server) Remove(_ context.Context, req *binocularpb.RemoveRequest) (*binocularpb.RemoveResponse, error) {
+	if err := server.binocular.Remove(req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &binocularpb.RemoveResponse{}, nil
+}
+
+// Search searches an index for a word.
+func (server *Server) Search(ctx context.Context, req *binocularpb.SearchRequest) (*binocularpb.SearchResponse, error) {
+	return server.FuzzySearch(ctx, &binocularpb.FuzzySearchRequest{
+		Word:             req.GetWord(),
+		Index:            req.GetIndex(),
+		Filters:          req.GetFilters(),
+		IncludeDocuments: req.GetIncludeDocuments(),
+	})
+}
+
+// FuzzySearch searches an index for a word within a Levenshtein distance.
+func (server *Server) FuzzySearch(ctx context.Context, req *binocularpb.FuzzySearchRequest) (*binocularpb.SearchResponse, error) {
+	hits, err := server.search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &binocularpb.SearchResponse{Hits: hits}, nil
+}
+
+// BulkAdd adds all streamed documents in batches of the configured bulk size.
+// Documents of already committed batches are kept if an error occurs.
+func (server *Server) BulkAdd(stream binocularpb.Binocular_BulkAddServer) error {
+	batch := server.binocular.NewBatch()
+	var count int64
+	commit := func() error {
+		n := batch.Len()
+		if err := batch.CommitContext(stream.Context()); err != nil {
+			return toStatus(err)
+		}
+		count += int64(n)
+		return nil
+	}
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		doc, err := readDocument(req.GetDocument())
+		if err != nil {
+			return err
+		}
+		if req.GetId() == "" {
+			batch.Add(doc)
+		} else {
+			batch.AddWithID(req.GetId(), doc)
+		}
+		if batch.Len() >= server.bulkSize {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := commit(); err != nil {
+		return err
+	}
+	return stream.SendAndClose(&binocularpb.BulkAddResponse{Count: count})
+}
+
+// StreamSearch streams the hits of a search, the most relevant first.
+func (server *Server) StreamSearch(req *binocularpb.FuzzySearchRequest, stream binocularpb.Binocular_StreamSearchServer) error {
+	hits, err := server.search(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	for _, hit := range hits {
+		if err := stream.Send(hit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searches the index and returns the hits with their documents if requested.
+func (server *Server) search(ctx context.Context, req *binocularpb.FuzzySearchRequest) ([]*binocularpb.Hit, error) {
+	if req.GetDistance() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid distance")
+	}
+	filters := make([]binocular.Filter, len(req.GetFilters()))
+	for i, f := range req.GetFilters() {
+		filters[i] = binocular.Filter{Index: f.GetIndex(), Value: f.GetValue()}
+	}
+	result, err := server.binocular.FuzzySearchContext(ctx, req.GetWord(), req.GetIndex(), int(req.GetDistance()), filters...)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	var docs []interface{}
+	if req.GetIncludeDocuments() {
+		docs, err = result.Collect()
+		if err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	// Hits are sorted by score while the documents are in the order of the refs
+	positions := make(map[string]int, len(result.Refs()))
+	for i, ref := range result.Refs() {
+		positions[ref] = i
+	}
+	hits := make([]*binocularpb.Hit, 0, len(result.Refs()))
+	for _, h := range result.Hits() {
+		hit := &binocularpb.Hit{Ref: h.Ref, Score: h.Score}
+		if docs != nil {
+			if hit.Document, err = marshalDocument(docs[positions[h.Ref]]); err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// validates the JSON document.
+func readDocument(doc []byte) (json.RawMessage, error) {
+	if !json.Valid(doc) {
+		return nil, status.Error(codes.InvalidArgument, "invalid JSON document")
+	}
+	return doc, nil
+}
+
+// returns the data as JSON, json.RawMessage is returned as it is.
+func marshalDocument(data interface{}) ([]byte, error) {
+	if raw, ok := data.(json.RawMessage); ok {
+		return raw, nil
+	}
+	return json.Marshal(data)
+}
+
+// maps the errors of a Binocular instance to a gRPC status.
+func toStatus(err error) error {
+	var limitErr *binocular.MemoryLimitError
+	switch {
+	case errors.Is(err, binocular.ErrIndexNotFound), errors.Is(err, binocular.ErrRefNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, binocular.ErrRefExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, binocular.ErrUnknownField):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &limitErr):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,218 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/mycreepy/go-binocular"
+	"github.com/mycreepy/go-binocular/grpcserver/binocularpb"
+)
+
+func newTestClient(t *testing.T, options ...Option) binocularpb.BinocularClient {
+	b := binocular.New(
+		binocular.WithFieldMapping("title", binocular.DefaultIndex),
+		binocular.WithFieldMapping("status", "status"),
+		binocular.WithIndex("status", binocular.WithKeyword()),
+		binocular.WithUniqueIDs(),
+	)
+	docs := map[string]string{
+		"1": `{"title":"Houston we have a problem","status":"published"}`,
+		"2": `{"title":"Houston we had a problem","status":"draft"}`,
+	}
+	for id, doc := range docs {
+		if err := b.AddWithID(id, json.RawMessage(doc)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, b, options...)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return binocularpb.NewBinocularClient(conn)
+}
+
+func TestServer_Documents(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	added, err := client.Add(ctx, &binocularpb.AddRequest{Document: []byte(`{"title":"rocket"}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got, err := client.Get(ctx, &binocularpb.GetRequest{Id: added.GetId()})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(got.GetDocument()) != `{"title":"rocket"}` {
+		t.Errorf("wrong document: %s", got.GetDocument())
+	}
+	if _, err := client.AddWithID(ctx, &binocularpb.AddWithIDRequest{Id: "3", Document: []byte(`{"title":"anvil"}`)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := client.Remove(ctx, &binocularpb.RemoveRequest{Id: "3"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"get not found", func() error {
+			_, err := client.Get(ctx, &binocularpb.GetRequest{Id: "3"})
+			return err
+		}, codes.NotFound},
+		{"remove not found", func() error {
+			_, err := client.Remove(ctx, &binocularpb.RemoveRequest{Id: "3"})
+			return err
+		}, codes.NotFound},
+		{"add existing id", func() error {
+			_, err := client.AddWithID(ctx, &binocularpb.AddWithIDRequest{Id: "1", Document: []byte(`{}`)})
+			return err
+		}, codes.AlreadyExists},
+		{"add invalid json", func() error {
+			_, err := client.Add(ctx, &binocularpb.AddRequest{Document: []byte(`{`)})
+			return err
+		}, codes.InvalidArgument},
+		{"search index not found", func() error {
+			_, err := client.Search(ctx, &binocularpb.SearchRequest{Word: "houston", Index: "unknown"})
+			return err
+		}, codes.NotFound},
+		{"search invalid distance", func() error {
+			_, err := client.FuzzySearch(ctx, &binocularpb.FuzzySearchRequest{Word: "houston", Index: binocular.DefaultIndex, Distance: -1})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call()); code != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestServer_Search(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	res, err := client.Search(ctx, &binocularpb.SearchRequest{
+		Word:             "houston",
+		Index:            binocular.DefaultIndex,
+		Filters:          []*binocularpb.Filter{{Index: "status", Value: "published"}},
+		IncludeDocuments: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(res.GetHits()) != 1 || res.GetHits()[0].GetRef() != "1" || res.GetHits()[0].GetScore() <= 0 {
+		t.Fatalf("wrong hits: %v", res.GetHits())
+	}
+	if string(res.GetHits()[0].GetDocument()) != `{"title":"Houston we have a problem","status":"published"}` {
+		t.Errorf("wrong document: %s", res.GetHits()[0].GetDocument())
+	}
+
+	res, err = client.FuzzySearch(ctx, &binocularpb.FuzzySearchRequest{Word: "hust", Index: binocular.DefaultIndex, Distance: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(res.GetHits()) != 2 || res.GetHits()[0].GetDocument() != nil {
+		t.Errorf("wrong hits: %v", res.GetHits())
+	}
+}
+
+func TestServer_StreamSearch(t *testing.T) {
+	client := newTestClient(t)
+	stream, err := client.StreamSearch(context.Background(), &binocularpb.FuzzySearchRequest{Word: "houston", Index: binocular.DefaultIndex})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	refs := make([]string, 0)
+	for {
+		hit, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		refs = append(refs, hit.GetRef())
+	}
+	if !reflect.DeepEqual(refs, []string{"1", "2"}) {
+		t.Errorf("wrong refs: %v", refs)
+	}
+
+	stream, err = client.StreamSearch(context.Background(), &binocularpb.FuzzySearchRequest{Word: "houston", Index: "unknown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.NotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestServer_BulkAdd(t *testing.T) {
+	client := newTestClient(t, WithBulkSize(2))
+	ctx := context.Background()
+	stream, err := client.BulkAdd(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, req := range []*binocularpb.AddWithIDRequest{
+		{Id: "3", Document: []byte(`{"title":"rocket one"}`)},
+		{Id: "4", Document: []byte(`{"title":"rocket two"}`)},
+		{Document: []byte(`{"title":"rocket three"}`)},
+	} {
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if res.GetCount() != 3 {
+		t.Errorf("wrong count: %d", res.GetCount())
+	}
+	search, err := client.Search(ctx, &binocularpb.SearchRequest{Word: "rocket", Index: binocular.DefaultIndex})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(search.GetHits()) != 3 {
+		t.Errorf("wrong hits: %v", search.GetHits())
+	}
+
+	stream, err = client.BulkAdd(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := stream.Send(&binocularpb.AddWithIDRequest{Id: "1", Document: []byte(`{}`)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("wrong error: %v", err)
+	}
+}
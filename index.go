@@ -1,21 +1,44 @@
 package binocular
 
 import (
+	"context"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/RoaringBitmap/roaring"
 	"github.com/kljensen/snowball"
 	"github.com/lithammer/fuzzysearch/fuzzy"
 )
 
+// cancelCheckInterval is the number of iterations after which long running loops check their context.
+const cancelCheckInterval = 1024
+
 // Index is a thread-safe inverted index.
+// The references of each term are stored as a compressed bitmap of document numbers.
 type Index struct {
-	mut  sync.RWMutex
-	data map[string][]string
+	mut   sync.RWMutex
+	data  map[string]*roaring.Bitmap
+	freqs map[string]map[uint32]uint32
+	refs  *refTable
+	// docs holds the numbers of all references stored for any term.
+	docs *roaring.Bitmap
+	// sharedRefs is set if the document numbers are shared with a Binocular instance which releases them.
+	sharedRefs bool
 
 	stemming       bool
 	keepStopWords  bool
 	keepShortWords bool
+	keyword        bool
+	frequencies    bool
+
+	// version is increased on every change so cached filters can detect stale postings.
+	version uint64
+
+	name    string
+	metrics Metrics
+	// options are the IndexOptions the Index has been created with.
+	options []IndexOption
 }
 
 // IndexOption alters the indexing behavior of an Index.
@@ -24,7 +47,10 @@ type IndexOption func(index *Index)
 // NewIndex creates a new Index with the given Options.
 func NewIndex(options ...IndexOption) *Index {
 	index := &Index{
-		data: make(map[string][]string),
+		data:    make(map[string]*roaring.Bitmap),
+		refs:    newRefTable(),
+		docs:    roaring.New(),
+		options: options,
 	}
 	for _, opt := range options {
 		opt(index)
@@ -54,86 +80,294 @@ func WithShortWords() IndexOption {
 	}
 }
 
+// WithKeyword indexes every added sentence verbatim as a single term instead of splitting it into words.
+// Stemming, stop words and short words have no effect on a keyword Index.
+func WithKeyword() IndexOption {
+	return func(index *Index) {
+		index.keyword = true
+	}
+}
+
+// WithFrequencies enables counting how often a term occurs for each reference.
+// Without it every reference is only stored once per term.
+func WithFrequencies() IndexOption {
+	return func(index *Index) {
+		index.frequencies = true
+		index.freqs = make(map[string]map[uint32]uint32)
+	}
+}
+
 // Add splits the given sentence into words and adds them with the reference to the data map.
 func (index *Index) Add(sentence string, ref string) {
-	for _, word := range strings.Split(sentence, " ") {
-		word = stripSpecialChars([]byte(word))
-		if index.stemming {
-			stemmed, err := snowball.Stem(word, "english", index.keepStopWords)
-			if err == nil {
-				index.mut.Lock()
-				index.data[stemmed] = append(index.data[stemmed], ref)
-				index.mut.Unlock()
-				continue
-			}
+	terms := index.analyze(sentence)
+	if len(terms) == 0 {
+		return
+	}
+	num := index.refs.num(ref)
+	index.lock()
+	defer index.mut.Unlock()
+	index.version++
+	index.docs.Add(num)
+	for _, term := range terms {
+		index.posting(term).Add(num)
+		index.count(term, num, 1)
+	}
+}
+
+// analyze splits the given sentence into the terms which are stored in the data map.
+func (index *Index) analyze(sentence string) []string {
+	terms := make([]string, 0)
+	index.tokenize(sentence, func(token Token) {
+		if !token.Dropped() {
+			terms = append(terms, token.Term)
 		}
-		wordLower := strings.ToLower(word)
-		if !index.keepShortWords && len(wordLower) <= 2 {
-			continue
+	})
+	if index.keyword && len(terms) == 0 {
+		return nil
+	}
+	return terms
+}
+
+// addPostings adds the references of each term to the data map while holding the lock only once.
+func (index *Index) addPostings(postings map[string][]string) {
+	nums := make(map[string][]uint32, len(postings))
+	for term, refs := range postings {
+		for _, ref := range refs {
+			nums[term] = append(nums[term], index.refs.num(ref))
 		}
-		if !index.keepStopWords && isStopWord(wordLower) {
-			continue
+	}
+	index.lock()
+	defer index.mut.Unlock()
+	index.version++
+	for term := range postings {
+		index.docs.AddMany(nums[term])
+		index.posting(term).AddMany(nums[term])
+		for _, num := range nums[term] {
+			index.count(term, num, 1)
 		}
-		index.mut.Lock()
-		index.data[wordLower] = append(index.data[wordLower], ref)
-		index.mut.Unlock()
 	}
 }
 
-// search returns a slice of references found for the given word.
-func (index *Index) search(word string) []string {
+// count increases the frequency of the term for the document number if frequencies are enabled.
+// The caller must hold the write lock.
+func (index *Index) count(term string, num uint32, n uint32) {
+	if !index.frequencies {
+		return
+	}
+	freqs, ok := index.freqs[term]
+	if !ok {
+		freqs = make(map[uint32]uint32)
+		index.freqs[term] = freqs
+	}
+	freqs[num] += n
+}
+
+// Frequency returns how often the given word occurs for the reference.
+// If WithFrequencies is not enabled it is 1 for every matching reference.
+func (index *Index) Frequency(word string, ref string) int {
+	num, ok := index.refs.lookup(ref)
+	if !ok {
+		return 0
+	}
+	term := index.normalize(word)
+	index.rLock()
+	defer index.mut.RUnlock()
+	bitmap, ok := index.data[term]
+	if !ok || !bitmap.Contains(num) {
+		return 0
+	}
+	if !index.frequencies {
+		return 1
+	}
+	return int(index.freqs[term][num])
+}
+
+// posting returns the bitmap of the term and creates it if it does not exist yet.
+// The caller must hold the write lock.
+func (index *Index) posting(term string) *roaring.Bitmap {
+	bitmap, ok := index.data[term]
+	if !ok {
+		bitmap = roaring.New()
+		index.data[term] = bitmap
+	}
+	return bitmap
+}
+
+// Postings returns the references stored for the exact term, which is not analyzed.
+// Use Analyze to get the terms a sentence is stored as.
+func (index *Index) Postings(term string) []string {
+	index.rLock()
+	defer index.mut.RUnlock()
+	bitmap, ok := index.data[term]
+	if !ok {
+		return []string{}
+	}
+	return index.refs.resolve(bitmap)
+}
+
+// normalize converts a search word into the term it is stored as.
+func (index *Index) normalize(word string) string {
+	if index.keyword {
+		return word
+	}
+	word = stripSpecialChars([]byte(strings.ToLower(word)))
 	if index.stemming {
 		stemmed, err := snowball.Stem(word, "english", index.keepStopWords)
 		if err == nil {
 			word = stemmed
 		}
 	}
-	index.mut.RLock()
-	defer index.mut.RUnlock()
-	return unique(index.data[word])
+	return word
+}
+
+// match returns a new bitmap with the document numbers of all terms matching the normalized word
+// within the Levenshtein distance and the matching terms. The caller must hold the read lock.
+func (index *Index) match(ctx context.Context, word string, distance int) (*roaring.Bitmap, []string, error) {
+	if distance <= 0 {
+		bitmap, ok := index.data[word]
+		if !ok {
+			return roaring.New(), nil, nil
+		}
+		return bitmap.Clone(), []string{word}, nil
+	}
+	matches := make([]*roaring.Bitmap, 0)
+	terms := make([]string, 0)
+	i := 0
+	for k, v := range index.data {
+		if i++; i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+		}
+		d := fuzzy.RankMatch(word, k)
+		if d > -1 && d <= distance {
+			matches = append(matches, v)
+			terms = append(terms, k)
+		}
+	}
+	return roaring.FastOr(matches...), terms, nil
 }
 
 // Search returns a slice of references found for the given word.
 // Distance is the Levenshtein distance.
 func (index *Index) Search(word string, distance int) []string {
-	searchWord := stripSpecialChars([]byte(strings.ToLower(word)))
-	if distance <= 0 {
-		return index.search(searchWord)
+	refs, _ := index.SearchContext(context.Background(), word, distance)
+	return refs
+}
+
+// SearchContext is like Search but stops a fuzzy search early and returns ctx.Err()
+// once the context is done.
+func (index *Index) SearchContext(ctx context.Context, word string, distance int) ([]string, error) {
+	return index.QueryContext(ctx, Query{All: []string{word}, Distance: distance})
+}
+
+// Query is a boolean combination of words which is evaluated against a single Index.
+// A reference matches if it matches every word of All, at least one word of Any and none of the words of None.
+// Empty lists are ignored, but at least one of All and Any is required for a reference to match.
+// Distance is the Levenshtein distance used to match every word.
+type Query struct {
+	All      []string
+	Any      []string
+	None     []string
+	Distance int
+}
+
+// Query returns a slice of references matching the given Query.
+func (index *Index) Query(query Query) []string {
+	refs, _ := index.QueryContext(context.Background(), query)
+	return refs
+}
+
+// QueryContext is like Query but stops a fuzzy search early and returns ctx.Err()
+// once the context is done.
+func (index *Index) QueryContext(ctx context.Context, query Query) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	if index.stemming {
-		stemmed, err := snowball.Stem(searchWord, "english", index.keepStopWords)
-		if err == nil {
-			searchWord = stemmed
+	start := time.Now()
+	index.rLock()
+	defer index.mut.RUnlock()
+	result, _, err := index.evaluate(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	refs := index.refs.resolve(result)
+	index.searched(query, start, len(refs))
+	return refs, nil
+}
+
+// evaluates the Query and returns the matching document numbers and the terms matched by All and Any.
+// The caller must hold the read lock.
+func (index *Index) evaluate(ctx context.Context, query Query) (*roaring.Bitmap, []string, error) {
+	if len(query.All) == 0 && len(query.Any) == 0 {
+		return roaring.New(), nil, nil
+	}
+	var result *roaring.Bitmap
+	matched := make([]string, 0, len(query.All)+len(query.Any))
+	for _, word := range query.All {
+		bitmap, terms, err := index.match(ctx, index.normalize(word), query.Distance)
+		if err != nil {
+			return nil, nil, err
 		}
+		matched = append(matched, terms...)
+		if result == nil {
+			result = bitmap
+			continue
+		}
+		result.And(bitmap)
 	}
-	index.mut.RLock()
-	defer index.mut.RUnlock()
-	refs := make([]string, 0)
-	for k, v := range index.data {
-		d := fuzzy.RankMatch(searchWord, k)
-		if d > -1 && d <= distance {
-			refs = append(refs, v...)
+	if len(query.Any) > 0 {
+		matches := make([]*roaring.Bitmap, len(query.Any))
+		for i, word := range query.Any {
+			bitmap, terms, err := index.match(ctx, index.normalize(word), query.Distance)
+			if err != nil {
+				return nil, nil, err
+			}
+			matched = append(matched, terms...)
+			matches[i] = bitmap
+		}
+		if result == nil {
+			result = roaring.FastOr(matches...)
+		} else {
+			result.And(roaring.FastOr(matches...))
 		}
 	}
-	return unique(refs)
+	for _, word := range query.None {
+		bitmap, _, err := index.match(ctx, index.normalize(word), query.Distance)
+		if err != nil {
+			return nil, nil, err
+		}
+		result.AndNot(bitmap)
+	}
+	return result, matched, nil
 }
 
-// Remove deletes the reference from the Index.
+// Remove deletes the reference from the Index. A standalone Index reuses its document number for new references.
 func (index *Index) Remove(ref string) {
-	for word, refs := range index.data {
-		for i, refInIndex := range refs {
-			if refInIndex == ref {
-				// if it's the last ref just delete the entry
-				if len(refs) == 1 {
-					index.mut.Lock()
-					delete(index.data, word)
-					index.mut.Unlock()
-					continue
-				}
-				// otherwise create a new slice of refs without the given ref
-				index.mut.Lock()
-				index.data[word] = removeElementFromSlice(refs, i)
-				index.mut.Unlock()
+	num, ok := index.refs.lookup(ref)
+	if !ok {
+		return
+	}
+	index.lock()
+	defer index.mut.Unlock()
+	if !index.docs.CheckedRemove(num) {
+		return
+	}
+	index.version++
+	if !index.sharedRefs {
+		defer index.refs.release(ref)
+	}
+	for term, bitmap := range index.data {
+		if !bitmap.CheckedRemove(num) {
+			continue
+		}
+		if bitmap.IsEmpty() {
+			delete(index.data, term)
+		}
+		if index.frequencies {
+			delete(index.freqs[term], num)
+			if len(index.freqs[term]) == 0 {
+				delete(index.freqs, term)
 			}
 		}
 	}
@@ -141,9 +375,17 @@ func (index *Index) Remove(ref string) {
 
 // Drop deletes the indexed data.
 func (index *Index) Drop() {
-	index.mut.Lock()
+	index.lock()
 	defer index.mut.Unlock()
-	index.data = make(map[string][]string)
+	index.version++
+	index.data = make(map[string]*roaring.Bitmap)
+	index.docs = roaring.New()
+	if !index.sharedRefs {
+		index.refs = newRefTable()
+	}
+	if index.frequencies {
+		index.freqs = make(map[string]map[uint32]uint32)
+	}
 }
 
 // copied from snowball package as it's unexported
@@ -184,23 +426,3 @@ func stripSpecialChars(s []byte) string {
 	}
 	return string(s[:j])
 }
-
-// swap the given data with the last element in the slice and drop it
-// copied from https://stackoverflow.com/questions/37334119/how-to-delete-an-element-from-a-slice-in-golang
-func removeElementFromSlice(s []string, i int) []string {
-	s[len(s)-1], s[i] = s[i], s[len(s)-1]
-	return s[:len(s)-1]
-}
-
-// deduplicate string slice
-func unique(in []string) []string {
-	keys := make(map[string]struct{})
-	list := make([]string, 0)
-	for _, entry := range in {
-		if _, value := keys[entry]; !value {
-			keys[entry] = struct{}{}
-			list = append(list, entry)
-		}
-	}
-	return list
-}
@@ -1,10 +1,14 @@
 package binocular
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"runtime"
 	"strconv"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/tjarratt/babble"
 )
 
@@ -133,6 +137,129 @@ func TestIndex_AddAndSearch(t *testing.T) {
 	}
 }
 
+func TestIndex_SearchContext_DeadlineExceeded(t *testing.T) {
+	index := NewIndex()
+	for i := 0; i < 2*cancelCheckInterval; i++ {
+		index.Add("term"+strconv.Itoa(i), strconv.Itoa(i))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	result, err := index.SearchContext(ctx, "term", 3)
+	if err != context.DeadlineExceeded {
+		t.Errorf("wrong error: %v", err)
+	}
+	if result != nil {
+		t.Error("result should be nil")
+	}
+}
+
+func TestIndex_Query(t *testing.T) {
+	index := NewIndex(WithStemming())
+	index.Add("Always look on the bright side of life", "1")
+	index.Add("The meaning of life", "2")
+	index.Add("Life of Brian", "3")
+	index.Add("Houston we have a problem", "4")
+	testdata := []struct {
+		name  string
+		query Query
+		refs  []string
+	}{
+		{
+			"all",
+			Query{All: []string{"life", "meaning"}},
+			[]string{"2"},
+		},
+		{
+			"any",
+			Query{Any: []string{"brian", "houston"}},
+			[]string{"3", "4"},
+		},
+		{
+			"all and any",
+			Query{All: []string{"life"}, Any: []string{"bright", "brian"}},
+			[]string{"1", "3"},
+		},
+		{
+			"none",
+			Query{All: []string{"life"}, None: []string{"brian"}},
+			[]string{"1", "2"},
+		},
+		{
+			"fuzzy",
+			Query{All: []string{"lif"}, None: []string{"meaning"}, Distance: 1},
+			[]string{"1", "3"},
+		},
+		{
+			"only none",
+			Query{None: []string{"brian"}},
+			[]string{},
+		},
+	}
+	for _, td := range testdata {
+		t.Run(td.name, func(t *testing.T) {
+			refs := index.Query(td.query)
+			if !reflect.DeepEqual(refs, td.refs) {
+				t.Errorf("expected %v, got %v", td.refs, refs)
+			}
+		})
+	}
+}
+
+func TestIndex_Add_RepeatedWords(t *testing.T) {
+	index := NewIndex()
+	index.Add("testing testing testing", "1")
+	index.Add("more testing", "2")
+	if index.data["testing"].GetCardinality() != 2 {
+		t.Errorf("refs should only be stored once, got %d", index.data["testing"].GetCardinality())
+	}
+	result := index.Search("testing", 0)
+	if !reflect.DeepEqual(result, []string{"1", "2"}) {
+		t.Errorf("wrong result: %v", result)
+	}
+	if index.Frequency("testing", "1") != 1 {
+		t.Error("frequency should be 1 without WithFrequencies")
+	}
+}
+
+func TestIndex_Add_SameRef(t *testing.T) {
+	index := NewIndex()
+	index.Add("Some testing data", "1")
+	index.Add("Some testing data", "1")
+	if index.data["testing"].GetCardinality() != 1 {
+		t.Errorf("refs should only be stored once, got %d", index.data["testing"].GetCardinality())
+	}
+	index.Remove("1")
+	if len(index.Search("testing", 0)) != 0 {
+		t.Error("result should be empty")
+	}
+}
+
+func TestWithFrequencies(t *testing.T) {
+	index := NewIndex(WithFrequencies(), WithStemming())
+	index.Add("cats and more cats", "1")
+	index.Add("a cat", "1")
+	index.Add("one cat", "2")
+	if index.data["cat"].GetCardinality() != 2 {
+		t.Errorf("refs should only be stored once, got %d", index.data["cat"].GetCardinality())
+	}
+	if index.Frequency("cats", "1") != 3 {
+		t.Errorf("expected frequency 3, got %d", index.Frequency("cats", "1"))
+	}
+	if index.Frequency("cat", "2") != 1 {
+		t.Errorf("expected frequency 1, got %d", index.Frequency("cat", "2"))
+	}
+	if index.Frequency("dog", "1") != 0 || index.Frequency("cat", "unknown") != 0 {
+		t.Error("expected frequency 0")
+	}
+	index.Remove("1")
+	if index.Frequency("cat", "1") != 0 {
+		t.Error("frequency should be removed")
+	}
+	if len(index.freqs["cat"]) != 1 {
+		t.Error("frequency of other refs should be kept")
+	}
+}
+
 func TestIndex_Remove(t *testing.T) {
 	index := NewIndex()
 	index.Add("Some testing data", "1")
@@ -151,6 +278,25 @@ func TestIndex_Remove(t *testing.T) {
 	}
 }
 
+func TestIndex_Remove_ReusesNumbers(t *testing.T) {
+	index := NewIndex()
+	for i := 0; i < 100; i++ {
+		ref := strconv.Itoa(i)
+		index.Add("Some testing data", ref)
+		index.Remove(ref)
+	}
+	index.Add("Other data", "new")
+	if len(index.refs.refs) != 1 {
+		t.Errorf("released numbers should be reused, got %d numbers", len(index.refs.refs))
+	}
+	if r := index.Search("testing", 0); len(r) != 0 {
+		t.Errorf("removed refs should not be found: %v", r)
+	}
+	if r := index.Search("data", 0); len(r) != 1 || r[0] != "new" {
+		t.Errorf("wrong result: %v", r)
+	}
+}
+
 func TestIndex_Drop(t *testing.T) {
 	index := NewIndex()
 	index.Add("Some testing data", "1")
@@ -161,6 +307,18 @@ func TestIndex_Drop(t *testing.T) {
 	}
 }
 
+func TestIndex_Keyword(t *testing.T) {
+	index := NewIndex(WithKeyword(), WithStemming())
+	index.Add("Acme Corp", "1")
+	index.Add("Globex", "2")
+	if len(index.Search("Acme Corp", 0)) != 1 {
+		t.Error("keyword should match verbatim")
+	}
+	if len(index.Search("acme", 0)) != 0 {
+		t.Error("keyword should not match partially")
+	}
+}
+
 func BenchmarkIndex_Add(b *testing.B) {
 	testdata := []struct {
 		name      string
@@ -290,6 +448,49 @@ func BenchmarkIndex_FuzzySearch(b *testing.B) {
 	}
 }
 
+func BenchmarkIndex_Memory(b *testing.B) {
+	testdata := []struct {
+		name      string
+		indexSize int
+		wordCount int
+	}{
+		{
+			"data size 1e+6",
+			1e+6,
+			10,
+		},
+	}
+	for _, td := range testdata {
+		b.Run(td.name, func(b *testing.B) {
+			babbler := babble.NewBabbler()
+			babbler.Separator = " "
+			babbler.Count = td.wordCount
+			sentences := make([]string, 1000)
+			for i := range sentences {
+				sentences[i] = babbler.Babble()
+			}
+			refs := make([]string, td.indexSize)
+			for i := range refs {
+				refs[i] = uuid.New().String()
+			}
+			var before, after runtime.MemStats
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				index := NewIndex()
+				for j, ref := range refs {
+					index.Add(sentences[j%len(sentences)], ref)
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(td.indexSize), "heap-B/doc")
+				runtime.KeepAlive(index)
+			}
+		})
+	}
+}
+
 func BenchmarkIndex_Remove(b *testing.B) {
 	testdata := []struct {
 		name      string
@@ -0,0 +1,60 @@
+package binocular
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// WithFieldMapping maps the dotted JSON path of a field to the Index with the given name.
+// Field mappings are used when adding a map[string]interface{} or json.RawMessage.
+// Nested objects are addressed with dots, e.g. "author.name", and arrays are
+// traversed transparently so "tags" matches every element of a tags array.
+func WithFieldMapping(path string, index string) Option {
+	return func(binocular *Binocular) {
+		binocular.mappings[path] = index
+	}
+}
+
+// parses the given raw JSON document and collects the mapped fields for their respective Index.
+func (binocular *Binocular) parseJSON(doc *document, raw json.RawMessage) error {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	binocular.parseValue(doc, "", v)
+	return nil
+}
+
+// walks the given value and collects all fields with a mapped path for their respective Index.
+func (binocular *Binocular) parseValue(doc *document, path string, v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if path != "" {
+				k = path + "." + k
+			}
+			binocular.parseValue(doc, k, child)
+		}
+	case []interface{}:
+		for _, child := range val {
+			binocular.parseValue(doc, path, child)
+		}
+	case string:
+		binocular.addMapped(doc, path, val)
+	case float64:
+		binocular.addMapped(doc, path, strconv.FormatFloat(val, 'f', -1, 64))
+	case json.Number:
+		binocular.addMapped(doc, path, val.String())
+	case bool:
+		binocular.addMapped(doc, path, strconv.FormatBool(val))
+	}
+}
+
+// collects the value for the Index mapped to the given path, unmapped paths are ignored.
+func (binocular *Binocular) addMapped(doc *document, path string, value string) {
+	name, ok := binocular.mappings[path]
+	if !ok {
+		return
+	}
+	doc.recordLocator[name] = append(doc.recordLocator[name], value)
+}
@@ -0,0 +1,93 @@
+package binocular
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestWithFieldMapping(t *testing.T) {
+	b := New(WithFieldMapping("author.name", "author"))
+	if b.mappings["author.name"] != "author" {
+		t.Error("wrong mapping")
+	}
+}
+
+func TestBinocular_AddWithID_Map(t *testing.T) {
+	b := New(
+		WithFieldMapping("title", DefaultIndex),
+		WithFieldMapping("author.name", "author"),
+		WithFieldMapping("tags", "tags"),
+		WithFieldMapping("comments.text", "comments"),
+		WithFieldMapping("year", "year"),
+	)
+	testdata := map[string]interface{}{
+		"title": "Always look on the bright side of life",
+		"author": map[string]interface{}{
+			"name": "Eric Idle",
+		},
+		"tags":     []interface{}{"comedy", "musical"},
+		"comments": []interface{}{map[string]interface{}{"text": "brilliant"}},
+		"year":     float64(1979),
+		"ignored":  "unmapped",
+	}
+	id := "123"
+	b.AddWithID(id, testdata)
+	if b.docs[id] == nil {
+		t.Fatal("document should exist")
+	}
+	if b.indices[DefaultIndex].Postings("bright")[0] != id {
+		t.Error("wrong id")
+	}
+	if b.indices["author"].Postings("idle")[0] != id {
+		t.Error("wrong id")
+	}
+	if b.indices["tags"].Postings("comedy")[0] != id || b.indices["tags"].Postings("musical")[0] != id {
+		t.Error("wrong id")
+	}
+	if b.indices["comments"].Postings("brilliant")[0] != id {
+		t.Error("wrong id")
+	}
+	if b.indices["year"].Postings("1979")[0] != id {
+		t.Error("wrong id")
+	}
+	if len(b.indices[DefaultIndex].Postings("unmapped")) != 0 {
+		t.Error("unmapped field should not be indexed")
+	}
+	if len(b.docs[id].recordLocator) != 5 {
+		t.Errorf("expected 5 indices in record locator, got %d", len(b.docs[id].recordLocator))
+	}
+}
+
+func TestBinocular_AddWithID_RawMessage(t *testing.T) {
+	b := New(WithFieldMapping("author.name", "author"))
+	testdata := json.RawMessage(`{"title": "Houston we have a problem", "author": {"name": "Jim Lovell"}}`)
+	id := "456"
+	b.AddWithID(id, testdata)
+	result, err := b.Search("lovell", "author")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 1 || result.Refs()[0] != id {
+		t.Error("wrong result")
+	}
+	data, err := result.Collect()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if string(data[0].(json.RawMessage)) != string(testdata) {
+		t.Error("wrong data")
+	}
+}
+
+func TestBinocular_AddWithID_RawMessage_Invalid(t *testing.T) {
+	b := New(WithFieldMapping("title", DefaultIndex))
+	id := "789"
+	var syntaxErr *json.SyntaxError
+	if err := b.AddWithID(id, json.RawMessage(`{"title": `)); !errors.As(err, &syntaxErr) {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, ok := b.docs[id]; ok {
+		t.Error("invalid document should not be added")
+	}
+}
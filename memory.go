@@ -0,0 +1,171 @@
+package binocular
+
+import (
+	"container/heap"
+	"container/list"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// rough sizes in bytes used to estimate the memory usage of maps, strings and postings
+const (
+	mapEntryBytes     = 48
+	stringHeaderBytes = 16
+	postingBytes      = 8
+	maxSizeDepth      = 32
+)
+
+// LimitPolicy determines what happens when adding data would exceed the memory limit of a Binocular instance.
+type LimitPolicy int
+
+const (
+	// RejectOnLimit rejects additions exceeding the memory limit with a *MemoryLimitError.
+	RejectOnLimit LimitPolicy = iota
+	// EvictOnLimit removes the oldest documents until the addition fits into the memory limit.
+	EvictOnLimit
+)
+
+// MemoryLimitError is returned if adding data would exceed the memory limit of a Binocular instance.
+type MemoryLimitError struct {
+	// Limit is the configured memory limit in bytes.
+	Limit int64
+	// Usage is the estimated memory usage in bytes before the addition.
+	Usage int64
+	// Size is the estimated size of the addition in bytes.
+	Size int64
+}
+
+func (err *MemoryLimitError) Error() string {
+	return fmt.Sprintf("memory limit of %d bytes exceeded: usage is %d bytes, addition needs %d bytes", err.Limit, err.Usage, err.Size)
+}
+
+// WithMemoryLimit limits the estimated memory usage of the documents and their postings to the given number of bytes.
+// The policy determines whether additions exceeding the limit are rejected or older documents are evicted.
+func WithMemoryLimit(bytes int64, policy LimitPolicy) Option {
+	return func(binocular *Binocular) {
+		binocular.memoryLimit = bytes
+		binocular.limitPolicy = policy
+	}
+}
+
+// reserve makes room for an addition of the given size and evicts the oldest documents
+// if the memory limit would be exceeded and EvictOnLimit is used. The given ids are never evicted.
+// A *MemoryLimitError is returned if the addition does not fit. The caller must hold the write lock.
+func (binocular *Binocular) reserve(size int64, keep map[string]struct{}) error {
+	if binocular.memoryLimit <= 0 || binocular.usage+size <= binocular.memoryLimit {
+		return nil
+	}
+	err := &MemoryLimitError{
+		Limit: binocular.memoryLimit,
+		Usage: binocular.usage,
+		Size:  size,
+	}
+	if binocular.limitPolicy != EvictOnLimit {
+		return err
+	}
+	// check if evicting is enough before removing anything
+	freeable := binocular.usage
+	for id := range keep {
+		if doc, ok := binocular.docs[id]; ok {
+			freeable -= doc.size
+		}
+	}
+	if binocular.usage-freeable+size > binocular.memoryLimit {
+		return err
+	}
+	for e := binocular.order.Front(); e != nil && binocular.usage+size > binocular.memoryLimit; {
+		id := e.Value.(string)
+		e = e.Next()
+		if _, ok := keep[id]; ok {
+			continue
+		}
+		binocular.evict(id)
+	}
+	return nil
+}
+
+// track updates the memory usage, the insertion order, the expiries and the use after the document has been put.
+// The caller must hold the write lock.
+func (binocular *Binocular) track(id string, old *document, doc *document) {
+	if old != nil {
+		binocular.usage -= old.size
+		binocular.order.Remove(old.elem)
+	}
+	binocular.usage += doc.size
+	doc.elem = binocular.order.PushBack(id)
+	if !doc.expires.IsZero() {
+		heap.Push(&binocular.expiries, expiry{id: id, at: doc.expires})
+	}
+	binocular.trackAccess(id, old, doc)
+}
+
+// restores the memory usage, insertion order, expiries and use for all documents. The caller must hold the write lock.
+func (binocular *Binocular) retrack() {
+	binocular.usage = 0
+	binocular.order = list.New()
+	binocular.expiries = nil
+	binocular.accessMut.Lock()
+	binocular.accesses = accessQueue{}
+	binocular.accessMut.Unlock()
+	for id, doc := range binocular.docs {
+		doc.size = estimateDocument(id, doc)
+		binocular.track(id, nil, doc)
+	}
+}
+
+// estimates the memory usage of the document including its postings.
+func estimateDocument(id string, doc *document) int64 {
+	size := mapEntryBytes + stringHeaderBytes + int64(len(id)) + sizeOf(reflect.ValueOf(doc.Data), 0)
+	for name, values := range doc.recordLocator {
+		size += mapEntryBytes + stringHeaderBytes + int64(len(name))
+		for _, value := range values {
+			// the value is stored in the record locator and at most once more as terms
+			size += 2*(stringHeaderBytes+int64(len(value))) + int64(strings.Count(value, " ")+1)*postingBytes
+		}
+	}
+	return size
+}
+
+// estimates the memory usage of the value including the memory it references.
+func sizeOf(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+	return int64(v.Type().Size()) + referencedSizeOf(v, depth)
+}
+
+// estimates the memory referenced by the value, e.g. the bytes of a string or the elements of a slice.
+func referencedSizeOf(v reflect.Value, depth int) int64 {
+	if depth > maxSizeDepth {
+		return 0
+	}
+	var size int64
+	switch v.Kind() {
+	case reflect.String:
+		size = int64(v.Len())
+	case reflect.Slice:
+		size = int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSizeOf(v.Index(i), depth+1)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSizeOf(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		it := v.MapRange()
+		for it.Next() {
+			size += mapEntryBytes + sizeOf(it.Key(), depth+1) + sizeOf(it.Value(), depth+1)
+		}
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			size = sizeOf(v.Elem(), depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSizeOf(v.Field(i), depth+1)
+		}
+	}
+	return size
+}
@@ -0,0 +1,112 @@
+package binocular
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithMemoryLimit_Reject(t *testing.T) {
+	b := New()
+	_ = b.AddWithID("1", "Always look on the bright side of life")
+	limit := b.Stats().Bytes
+	b = New(WithMemoryLimit(limit, RejectOnLimit))
+	err := b.AddWithID("1", "Always look on the bright side of life")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = b.AddWithID("2", "Houston we have a problem")
+	var limitErr *MemoryLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if limitErr.Limit != limit || limitErr.Usage != limit || limitErr.Size <= 0 {
+		t.Errorf("wrong error values: %+v", limitErr)
+	}
+	if b.docs["2"] != nil {
+		t.Error("document should not exist")
+	}
+	err = b.AddWithID("1", "Always look on the bright side")
+	if err != nil {
+		t.Errorf("replacing with smaller data should fit: %s", err)
+	}
+}
+
+func TestWithMemoryLimit_Evict(t *testing.T) {
+	b := New()
+	_ = b.AddWithID("1", "Lorem ipsum")
+	limit := 2 * b.Stats().Bytes
+	b = New(WithMemoryLimit(limit, EvictOnLimit))
+	_ = b.AddWithID("1", "Lorem ipsum")
+	_ = b.AddWithID("2", "Lorem ipsum")
+	_ = b.AddWithID("1", "Lorem ipsum")
+	err := b.AddWithID("3", "Lorem ipsum")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b.docs["2"] != nil {
+		t.Error("oldest document should be evicted")
+	}
+	result, err := b.Search("lorem", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(result.Refs(), []string{"1", "3"}) {
+		t.Errorf("wrong result: %v", result.Refs())
+	}
+	if b.Stats().Bytes > limit {
+		t.Error("usage should not exceed the limit")
+	}
+	err = b.AddWithID("4", "Lorem ipsum dolor sit amet, consetetur sadipscing elitr, sed diam nonumy eirmod tempor")
+	var limitErr *MemoryLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if len(b.docs) != 2 {
+		t.Error("nothing should be evicted if the addition does not fit at all")
+	}
+}
+
+func TestBatch_Commit_MemoryLimit(t *testing.T) {
+	b := New(WithMemoryLimit(1, RejectOnLimit))
+	batch := b.NewBatch()
+	batch.AddWithID("1", "Lorem ipsum")
+	err := batch.Commit()
+	var limitErr *MemoryLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if len(b.docs) != 0 {
+		t.Error("no document should be added")
+	}
+}
+
+func TestSizeOf(t *testing.T) {
+	type nested struct {
+		S string
+		M map[string]int
+		P *string
+	}
+	s := "12345678"
+	testdata := []struct {
+		name string
+		data interface{}
+		size int64
+	}{
+		{"string", "1234", 20},
+		{"struct", nested{S: s, P: &s}, 32 + 8 + 24},
+		{"map", map[string]interface{}{"a": "b"}, 8 + mapEntryBytes + 17 + 16 + 17},
+		{"nil", nil, 0},
+	}
+	for _, td := range testdata {
+		t.Run(td.name, func(t *testing.T) {
+			var v reflect.Value
+			if td.data != nil {
+				v = reflect.ValueOf(td.data)
+			}
+			if size := sizeOf(v, 0); size != td.size {
+				t.Errorf("expected %d, got %d", td.size, size)
+			}
+		})
+	}
+}
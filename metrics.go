@@ -0,0 +1,114 @@
+package binocular
+
+import (
+	"time"
+)
+
+// SearchType is the kind of a search reported to Metrics.
+type SearchType int
+
+const (
+	// ExactSearch is a search for the exact terms of the words.
+	ExactSearch SearchType = iota
+	// FuzzySearch is a search within a Levenshtein distance.
+	FuzzySearch
+)
+
+func (typ SearchType) String() string {
+	switch typ {
+	case ExactSearch:
+		return "exact"
+	case FuzzySearch:
+		return "fuzzy"
+	default:
+		return "unknown"
+	}
+}
+
+// Metrics receives measurements of a Binocular instance and its indices.
+// The methods are called synchronously, partly while holding locks, so they must be fast and safe for concurrent use.
+// Current sizes like the number of terms and documents are not reported, use Stats to read them instead.
+type Metrics interface {
+	// DocumentsAdded is called with the number of documents added with a new id.
+	DocumentsAdded(n int)
+	// DocumentsRemoved is called with the number of documents removed, including evictions and expiries.
+	DocumentsRemoved(n int)
+	// Searched is called after a successful search of the named Index with its duration and number of results.
+	Searched(index string, typ SearchType, duration time.Duration, results int)
+	// LockWaited is called with the time it took to acquire the lock of the named Index.
+	LockWaited(index string, duration time.Duration)
+}
+
+// TenantMetrics is implemented by Metrics which report the measurements of tenants separately.
+type TenantMetrics interface {
+	Metrics
+	// Tenant returns the Metrics receiving the measurements of the tenant with the given name.
+	Tenant(name string) Metrics
+}
+
+// WithMetrics reports the measurements of the Binocular instance, its indices and its tenants to the Metrics.
+// Tenants report to the Metrics returned by Tenant if the Metrics implement TenantMetrics.
+// RestoreContext replaces all data without reporting documents.
+func WithMetrics(metrics Metrics) Option {
+	return func(binocular *Binocular) {
+		binocular.metrics = metrics
+	}
+}
+
+// WithIndexMetrics reports the searches and lock wait times of the Index to the Metrics under the given name.
+// Indices of a Binocular instance are reported under their name with WithMetrics.
+func WithIndexMetrics(name string, metrics Metrics) IndexOption {
+	return func(index *Index) {
+		index.name = name
+		index.metrics = metrics
+	}
+}
+
+// tenantMetrics returns the Metrics the tenant with the given name reports to.
+func tenantMetrics(metrics Metrics, name string) Metrics {
+	if tm, ok := metrics.(TenantMetrics); ok {
+		return tm.Tenant(name)
+	}
+	return metrics
+}
+
+// instrument reports the measurements of the Index under the given name if WithMetrics is used.
+func (binocular *Binocular) instrument(name string, index *Index) {
+	if binocular.metrics != nil {
+		WithIndexMetrics(name, binocular.metrics)(index)
+	}
+}
+
+// lock acquires the write lock of the Index and reports the time it took.
+func (index *Index) lock() {
+	if index.metrics == nil {
+		index.mut.Lock()
+		return
+	}
+	start := time.Now()
+	index.mut.Lock()
+	index.metrics.LockWaited(index.name, time.Since(start))
+}
+
+// rLock acquires the read lock of the Index and reports the time it took.
+func (index *Index) rLock() {
+	if index.metrics == nil {
+		index.mut.RLock()
+		return
+	}
+	start := time.Now()
+	index.mut.RLock()
+	index.metrics.LockWaited(index.name, time.Since(start))
+}
+
+// searched reports a search of the Index which started at the given time.
+func (index *Index) searched(query Query, start time.Time, results int) {
+	if index.metrics == nil {
+		return
+	}
+	typ := ExactSearch
+	if query.Distance > 0 {
+		typ = FuzzySearch
+	}
+	index.metrics.Searched(index.name, typ, time.Since(start), results)
+}
@@ -0,0 +1,166 @@
+package binocular
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type search struct {
+	index   string
+	typ     SearchType
+	results int
+}
+
+// recorder is a Metrics implementation which records all measurements.
+type recorder struct {
+	mut      sync.Mutex
+	added    int
+	removed  int
+	searches []search
+	waits    map[string]int
+}
+
+func (r *recorder) DocumentsAdded(n int) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.added += n
+}
+
+func (r *recorder) DocumentsRemoved(n int) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.removed += n
+}
+
+func (r *recorder) Searched(index string, typ SearchType, duration time.Duration, results int) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.searches = append(r.searches, search{index, typ, results})
+}
+
+func (r *recorder) LockWaited(index string, duration time.Duration) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.waits == nil {
+		r.waits = make(map[string]int)
+	}
+	r.waits[index]++
+}
+
+func TestWithMetrics(t *testing.T) {
+	r := &recorder{}
+	b := New(WithIndex("title"), WithMetrics(r))
+	type doc struct {
+		Text  string `binocular:"default"`
+		Title string `binocular:"title"`
+		Tag   string `binocular:"tag"`
+	}
+	if err := b.AddWithID("1", doc{"rocket launch", "rocket", "space"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.AddWithID("1", doc{"rocket fuel", "rocket", "space"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	batch := b.NewBatch()
+	batch.AddWithID("2", doc{"anvil", "anvil", "tools"})
+	batch.AddWithID("3", doc{"rocket", "rocket", "space"})
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.Search("rocket", "title"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.FuzzySearch("spac", "tag", 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.Remove("2"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if r.added != 3 {
+		t.Errorf("expected 3 added documents, got %d", r.added)
+	}
+	if r.removed != 1 {
+		t.Errorf("expected 1 removed document, got %d", r.removed)
+	}
+	expected := []search{{"title", ExactSearch, 2}, {"tag", FuzzySearch, 2}}
+	if len(r.searches) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, r.searches)
+	}
+	for i, s := range expected {
+		if r.searches[i] != s {
+			t.Errorf("expected %v, got %v", s, r.searches[i])
+		}
+	}
+	for _, name := range []string{DefaultIndex, "title", "tag"} {
+		if r.waits[name] == 0 {
+			t.Errorf("lock wait of index %s should be reported", name)
+		}
+	}
+}
+
+// tenantRecorder is a TenantMetrics implementation which records the measurements of each tenant separately.
+type tenantRecorder struct {
+	recorder
+	tenantMut sync.Mutex
+	tenants   map[string]*recorder
+}
+
+func (r *tenantRecorder) Tenant(name string) Metrics {
+	r.tenantMut.Lock()
+	defer r.tenantMut.Unlock()
+	if r.tenants[name] == nil {
+		r.tenants[name] = &recorder{}
+	}
+	return r.tenants[name]
+}
+
+func TestWithMetrics_Tenant(t *testing.T) {
+	r := &recorder{}
+	tr := &tenantRecorder{tenants: map[string]*recorder{}}
+	for _, metrics := range []Metrics{r, tr} {
+		b := New(WithIndex("title"), WithMetrics(metrics))
+		acme := b.Tenant("acme")
+		if err := acme.AddWithID("1", "rocket"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := acme.Search("rocket", DefaultIndex); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := b.DropTenant("acme"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	for name, r := range map[string]*recorder{"inherited": r, "tenant": tr.tenants["acme"]} {
+		if r == nil || r.added != 1 || r.removed != 1 || len(r.searches) != 1 || r.waits[DefaultIndex] == 0 {
+			t.Errorf("%s metrics should count the tenant: %+v", name, r)
+		}
+	}
+	if tr.added != 0 || len(tr.searches) != 0 {
+		t.Error("tenant metrics should not be reported to the metrics of the instance")
+	}
+}
+
+func TestWithIndexMetrics(t *testing.T) {
+	r := &recorder{}
+	index := NewIndex(WithIndexMetrics("standalone", r))
+	index.Add("rocket", "1")
+	if refs := index.Search("rockt", 1); len(refs) != 1 {
+		t.Fatalf("wrong result len")
+	}
+	if len(r.searches) != 1 || r.searches[0] != (search{"standalone", FuzzySearch, 1}) {
+		t.Errorf("wrong searches: %v", r.searches)
+	}
+	if r.waits["standalone"] != 2 {
+		t.Errorf("expected 2 lock waits, got %d", r.waits["standalone"])
+	}
+}
+
+func TestSearchType_String(t *testing.T) {
+	types := map[SearchType]string{ExactSearch: "exact", FuzzySearch: "fuzzy", SearchType(-1): "unknown"}
+	for typ, expected := range types {
+		if typ.String() != expected {
+			t.Errorf("expected %s, got %s", expected, typ)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package binocular
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fatih/structtag"
+)
+
+// structPlan holds the tagged string and numeric fields of a struct type so the tags only need to be parsed once.
+// Numeric fields are only indexed if their tag has the "numeric" option.
+// Expires is the index sequence of the exported time.Time field tagged with the "expires" option or nil.
+type structPlan struct {
+	fields  []planField
+	expires []int
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// planField is a tagged field with its index sequence, the name from its `binocular` tag and its FieldType.
+type planField struct {
+	index []int
+	name  string
+	typ   FieldType
+}
+
+// structPlans caches the structPlan of every struct type, it is safe for concurrent use.
+var structPlans sync.Map // map[reflect.Type]*structPlan
+
+// returns the cached structPlan of the given struct type and creates it on first use.
+func cachedStructPlan(t reflect.Type) *structPlan {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.(*structPlan)
+	}
+	plan, _ := structPlans.LoadOrStore(t, newStructPlan(t))
+	return plan.(*structPlan)
+}
+
+// parses the `binocular` tags of the given struct type including its nested structs.
+func newStructPlan(t reflect.Type) *structPlan {
+	plan := &structPlan{}
+	plan.collect(t, nil)
+	return plan
+}
+
+func (plan *structPlan) collect(t reflect.Type, path []int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(path[:len(path):len(path)], i)
+		switch f.Type.Kind() {
+		case reflect.String:
+			bt, ok := binocularTag(f)
+			if !ok {
+				break
+			}
+			typ := TextField
+			if bt.HasOption("keyword") {
+				typ = KeywordField
+			}
+			plan.fields = append(plan.fields, planField{index: index, name: bt.Name, typ: typ})
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			bt, ok := binocularTag(f)
+			if !ok || !bt.HasOption("numeric") {
+				break
+			}
+			plan.fields = append(plan.fields, planField{index: index, name: bt.Name, typ: NumericField})
+		case reflect.Struct:
+			if f.Type == timeType {
+				if bt, ok := binocularTag(f); ok && bt.HasOption("expires") && f.IsExported() && plan.expires == nil {
+					plan.expires = index
+				}
+				break
+			}
+			plan.collect(f.Type, index)
+		}
+	}
+}
+
+// returns the `binocular` tag of the field and whether it has one.
+func binocularTag(f reflect.StructField) (*structtag.Tag, bool) {
+	tags, err := structtag.Parse(string(f.Tag))
+	if err != nil {
+		return nil, false
+	}
+	bt, err := tags.Get("binocular")
+	if err != nil {
+		return nil, false
+	}
+	return bt, true
+}
+
+// formats the value of a planned field as string.
+func formatField(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return v.String()
+}
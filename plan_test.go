@@ -0,0 +1,141 @@
+package binocular
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tjarratt/babble"
+)
+
+type planTestDoc struct {
+	Title  string `binocular:"default"`
+	Author struct {
+		Name  string `binocular:"author"`
+		Email string
+	}
+	Tags  string `binocular:"tags,keyword"`
+	Count int    `binocular:"count,numeric"`
+	Pages int    `binocular:"pages"`
+}
+
+type planTestUnexported struct {
+	User    string    `binocular:"default"`
+	expires time.Time `binocular:",expires"`
+}
+
+func TestNewStructPlan(t *testing.T) {
+	plan := newStructPlan(reflect.TypeOf(planTestDoc{}))
+	expected := []planField{
+		{index: []int{0}, name: "default"},
+		{index: []int{1, 0}, name: "author"},
+		{index: []int{2}, name: "tags", typ: KeywordField},
+		{index: []int{3}, name: "count", typ: NumericField},
+	}
+	if !reflect.DeepEqual(plan.fields, expected) {
+		t.Errorf("wrong plan: %v", plan.fields)
+	}
+	if plan.expires != nil {
+		t.Error("plan should have no expires field")
+	}
+	plan = newStructPlan(reflect.TypeOf(ttlTestSession{}))
+	if !reflect.DeepEqual(plan.expires, []int{1}) {
+		t.Errorf("wrong expires field: %v", plan.expires)
+	}
+	plan = newStructPlan(reflect.TypeOf(planTestUnexported{}))
+	if plan.expires != nil {
+		t.Errorf("unexported expires field should be skipped: %v", plan.expires)
+	}
+}
+
+func TestBinocular_Add_UnexportedExpiresField(t *testing.T) {
+	b := New()
+	if err := b.AddWithID("1", planTestUnexported{User: "brian", expires: time.Now()}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.Get("1"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestBinocular_Add_NumericField(t *testing.T) {
+	b := New()
+	doc := planTestDoc{Title: "rocket", Count: 3, Pages: 7}
+	if err := b.AddWithID("1", doc); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := b.Search("3", "count")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(result.Refs(), []string{"1"}) {
+		t.Errorf("numeric field should be indexed: %v", result.Refs())
+	}
+	if _, ok := b.indices["pages"]; ok {
+		t.Error("numeric field without the numeric option should not be indexed")
+	}
+}
+
+func TestCachedStructPlan(t *testing.T) {
+	typ := reflect.TypeOf(planTestDoc{})
+	plans := make([]*structPlan, 10)
+	var wg sync.WaitGroup
+	for i := range plans {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			plans[i] = cachedStructPlan(typ)
+		}(i)
+	}
+	wg.Wait()
+	for _, plan := range plans {
+		if plan != plans[0] {
+			t.Fatal("plan should only be created once")
+		}
+	}
+}
+
+func BenchmarkBinocular_AddWithID_Struct(b *testing.B) {
+	testdata := []struct {
+		name     string
+		cached   bool
+		docCount int
+	}{
+		{
+			"uncached",
+			false,
+			1e+6,
+		},
+		{
+			"cached",
+			true,
+			1e+6,
+		},
+	}
+	babbler := babble.NewBabbler()
+	babbler.Separator = " "
+	babbler.Count = 5
+	docs := make([]planTestDoc, 1000)
+	for i := range docs {
+		docs[i].Title = babbler.Babble()
+		docs[i].Author.Name = babbler.Babble()
+		docs[i].Tags = babbler.Babble()
+	}
+	typ := reflect.TypeOf(planTestDoc{})
+	for _, td := range testdata {
+		b.Run(td.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bin := New()
+				for j := 0; j < td.docCount; j++ {
+					var plan *structPlan
+					if !td.cached {
+						plan = newStructPlan(typ)
+					}
+					_ = bin.put(strconv.Itoa(j), docs[j%len(docs)], plan, putAdd, 0)
+				}
+			}
+		})
+	}
+}
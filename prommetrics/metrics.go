@@ -0,0 +1,211 @@
+// Package prommetrics exports the measurements of a Binocular instance as Prometheus metrics.
+//
+// The following metrics are exported, prefixed with the namespace:
+//
+//	documents_added_total            counter   documents added with a new id by tenant
+//	documents_removed_total          counter   documents removed, including evictions and expiries, by tenant
+//	searches_total                   counter   searches by tenant, index and type
+//	search_duration_seconds          histogram search latency by tenant, index and type
+//	search_results                   histogram number of results by tenant, index and type
+//	index_lock_wait_seconds          histogram time to acquire the lock of an index by tenant and index
+//	documents                        gauge     current number of documents of the observed instance by tenant
+//	index_terms                      gauge     current number of distinct terms by tenant and index of the observed instance
+//	index_docs                       gauge     current number of distinct references by tenant and index of the observed instance
+//
+// The tenant label is empty for the Binocular instance itself.
+// The gauges are read from binocular.Stats and binocular.TenantStats on every scrape once an instance has been observed.
+package prommetrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mycreepy/go-binocular"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultNamespace is the default prefix of all metric names.
+const DefaultNamespace = "binocular"
+
+// Metrics implements binocular.Metrics and prometheus.Collector.
+type Metrics struct {
+	namespace string
+
+	documentsAdded   *prometheus.CounterVec
+	documentsRemoved *prometheus.CounterVec
+	searches         *prometheus.CounterVec
+	searchDuration   *prometheus.HistogramVec
+	searchResults    *prometheus.HistogramVec
+	lockWait         *prometheus.HistogramVec
+
+	documents  *prometheus.Desc
+	indexTerms *prometheus.Desc
+	indexDocs  *prometheus.Desc
+
+	mut       sync.RWMutex
+	binocular *binocular.Binocular
+}
+
+// Option can alter the behavior of Metrics.
+type Option func(metrics *Metrics)
+
+// WithNamespace prefixes all metric names with the given namespace instead of DefaultNamespace.
+func WithNamespace(namespace string) Option {
+	return func(metrics *Metrics) {
+		metrics.namespace = namespace
+	}
+}
+
+// New creates new Metrics with the given Options. Pass them to binocular.WithMetrics
+// and register them with a prometheus.Registerer.
+func New(options ...Option) *Metrics {
+	metrics := &Metrics{
+		namespace: DefaultNamespace,
+	}
+	for _, opt := range options {
+		opt(metrics)
+	}
+	ns := metrics.namespace
+	metrics.documentsAdded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns,
+		Name:      "documents_added_total",
+		Help:      "Number of documents added with a new id.",
+	}, []string{"tenant"})
+	metrics.documentsRemoved = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns,
+		Name:      "documents_removed_total",
+		Help:      "Number of documents removed, including evictions and expiries.",
+	}, []string{"tenant"})
+	metrics.searches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns,
+		Name:      "searches_total",
+		Help:      "Number of searches by index and type.",
+	}, []string{"tenant", "index", "type"})
+	metrics.searchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: ns,
+		Name:      "search_duration_seconds",
+		Help:      "Duration of searches by index and type.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+	}, []string{"tenant", "index", "type"})
+	metrics.searchResults = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: ns,
+		Name:      "search_results",
+		Help:      "Number of results of searches by index and type.",
+		Buckets:   append([]float64{0}, prometheus.ExponentialBuckets(1, 4, 10)...),
+	}, []string{"tenant", "index", "type"})
+	metrics.lockWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: ns,
+		Name:      "index_lock_wait_seconds",
+		Help:      "Time it took to acquire the lock of an index.",
+		Buckets:   prometheus.ExponentialBuckets(0.000001, 4, 10),
+	}, []string{"tenant", "index"})
+	metrics.documents = prometheus.NewDesc(prometheus.BuildFQName(ns, "", "documents"),
+		"Current number of documents.", []string{"tenant"}, nil)
+	metrics.indexTerms = prometheus.NewDesc(prometheus.BuildFQName(ns, "", "index_terms"),
+		"Current number of distinct terms by index.", []string{"tenant", "index"}, nil)
+	metrics.indexDocs = prometheus.NewDesc(prometheus.BuildFQName(ns, "", "index_docs"),
+		"Current number of distinct references by index.", []string{"tenant", "index"}, nil)
+	return metrics
+}
+
+// Observe exports the current number of documents, terms and references of the Binocular instance as gauges.
+// Only one instance is observed at a time, observing another one replaces it.
+func (metrics *Metrics) Observe(b *binocular.Binocular) {
+	metrics.mut.Lock()
+	defer metrics.mut.Unlock()
+	metrics.binocular = b
+}
+
+// DocumentsAdded implements binocular.Metrics.
+func (metrics *Metrics) DocumentsAdded(n int) {
+	tenantMetrics{metrics, ""}.DocumentsAdded(n)
+}
+
+// DocumentsRemoved implements binocular.Metrics.
+func (metrics *Metrics) DocumentsRemoved(n int) {
+	tenantMetrics{metrics, ""}.DocumentsRemoved(n)
+}
+
+// Searched implements binocular.Metrics.
+func (metrics *Metrics) Searched(index string, typ binocular.SearchType, duration time.Duration, results int) {
+	tenantMetrics{metrics, ""}.Searched(index, typ, duration, results)
+}
+
+// LockWaited implements binocular.Metrics.
+func (metrics *Metrics) LockWaited(index string, duration time.Duration) {
+	tenantMetrics{metrics, ""}.LockWaited(index, duration)
+}
+
+// Tenant implements binocular.TenantMetrics.
+func (metrics *Metrics) Tenant(name string) binocular.Metrics {
+	return tenantMetrics{metrics, name}
+}
+
+// tenantMetrics reports the measurements of a tenant with its name as tenant label.
+type tenantMetrics struct {
+	metrics *Metrics
+	tenant  string
+}
+
+func (t tenantMetrics) DocumentsAdded(n int) {
+	t.metrics.documentsAdded.WithLabelValues(t.tenant).Add(float64(n))
+}
+
+func (t tenantMetrics) DocumentsRemoved(n int) {
+	t.metrics.documentsRemoved.WithLabelValues(t.tenant).Add(float64(n))
+}
+
+func (t tenantMetrics) Searched(index string, typ binocular.SearchType, duration time.Duration, results int) {
+	t.metrics.searches.WithLabelValues(t.tenant, index, typ.String()).Inc()
+	t.metrics.searchDuration.WithLabelValues(t.tenant, index, typ.String()).Observe(duration.Seconds())
+	t.metrics.searchResults.WithLabelValues(t.tenant, index, typ.String()).Observe(float64(results))
+}
+
+func (t tenantMetrics) LockWaited(index string, duration time.Duration) {
+	t.metrics.lockWait.WithLabelValues(t.tenant, index).Observe(duration.Seconds())
+}
+
+// Describe implements prometheus.Collector.
+func (metrics *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	metrics.documentsAdded.Describe(ch)
+	metrics.documentsRemoved.Describe(ch)
+	metrics.searches.Describe(ch)
+	metrics.searchDuration.Describe(ch)
+	metrics.searchResults.Describe(ch)
+	metrics.lockWait.Describe(ch)
+	ch <- metrics.documents
+	ch <- metrics.indexTerms
+	ch <- metrics.indexDocs
+}
+
+// Collect implements prometheus.Collector.
+func (metrics *Metrics) Collect(ch chan<- prometheus.Metric) {
+	metrics.documentsAdded.Collect(ch)
+	metrics.documentsRemoved.Collect(ch)
+	metrics.searches.Collect(ch)
+	metrics.searchDuration.Collect(ch)
+	metrics.searchResults.Collect(ch)
+	metrics.lockWait.Collect(ch)
+	metrics.mut.RLock()
+	b := metrics.binocular
+	metrics.mut.RUnlock()
+	if b == nil {
+		return
+	}
+	metrics.collectStats(ch, "", b.Stats())
+	for _, tenant := range b.Tenants() {
+		// skip tenants dropped since listing them
+		if stats, err := b.TenantStats(tenant); err == nil {
+			metrics.collectStats(ch, tenant, stats)
+		}
+	}
+}
+
+// sends the gauges of the stats with the given tenant label.
+func (metrics *Metrics) collectStats(ch chan<- prometheus.Metric, tenant string, stats binocular.Stats) {
+	ch <- prometheus.MustNewConstMetric(metrics.documents, prometheus.GaugeValue, float64(stats.Docs), tenant)
+	for name, index := range stats.Indices {
+		ch <- prometheus.MustNewConstMetric(metrics.indexTerms, prometheus.GaugeValue, float64(index.Terms), tenant, name)
+		ch <- prometheus.MustNewConstMetric(metrics.indexDocs, prometheus.GaugeValue, float64(index.Docs), tenant, name)
+	}
+}
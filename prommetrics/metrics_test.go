@@ -0,0 +1,156 @@
+package prommetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mycreepy/go-binocular"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := New()
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(metrics); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b := binocular.New(binocular.WithMetrics(metrics))
+	metrics.Observe(b)
+	for id, data := range []string{"rocket launch", "rocket fuel", "anvil"} {
+		if err := b.AddWithID(string(rune('1'+id)), data); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := b.Remove("3"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.Search("rocket", binocular.DefaultIndex); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.FuzzySearch("lanch", binocular.DefaultIndex, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `
+# HELP binocular_documents Current number of documents.
+# TYPE binocular_documents gauge
+binocular_documents{tenant=""} 2
+# HELP binocular_documents_added_total Number of documents added with a new id.
+# TYPE binocular_documents_added_total counter
+binocular_documents_added_total{tenant=""} 3
+# HELP binocular_documents_removed_total Number of documents removed, including evictions and expiries.
+# TYPE binocular_documents_removed_total counter
+binocular_documents_removed_total{tenant=""} 1
+# HELP binocular_index_docs Current number of distinct references by index.
+# TYPE binocular_index_docs gauge
+binocular_index_docs{index="default",tenant=""} 2
+# HELP binocular_index_terms Current number of distinct terms by index.
+# TYPE binocular_index_terms gauge
+binocular_index_terms{index="default",tenant=""} 3
+# HELP binocular_searches_total Number of searches by index and type.
+# TYPE binocular_searches_total counter
+binocular_searches_total{index="default",tenant="",type="exact"} 1
+binocular_searches_total{index="default",tenant="",type="fuzzy"} 1
+`
+	names := []string{
+		"binocular_documents",
+		"binocular_documents_added_total",
+		"binocular_documents_removed_total",
+		"binocular_index_docs",
+		"binocular_index_terms",
+		"binocular_searches_total",
+	}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), names...); err != nil {
+		t.Errorf("unexpected metrics: %s", err)
+	}
+	if n := testutil.CollectAndCount(metrics.searchResults); n != 2 {
+		t.Errorf("expected 2 result histograms, got %d", n)
+	}
+	if n := testutil.CollectAndCount(metrics.lockWait); n != 1 {
+		t.Errorf("expected 1 lock wait histogram, got %d", n)
+	}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP binocular_search_results Number of results of searches by index and type.
+# TYPE binocular_search_results histogram
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="0"} 0
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="1"} 0
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="4"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="16"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="64"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="256"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="1024"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="4096"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="16384"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="65536"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="262144"} 1
+binocular_search_results_bucket{index="default",tenant="",type="exact",le="+Inf"} 1
+binocular_search_results_sum{index="default",tenant="",type="exact"} 2
+binocular_search_results_count{index="default",tenant="",type="exact"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="0"} 0
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="1"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="4"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="16"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="64"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="256"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="1024"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="4096"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="16384"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="65536"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="262144"} 1
+binocular_search_results_bucket{index="default",tenant="",type="fuzzy",le="+Inf"} 1
+binocular_search_results_sum{index="default",tenant="",type="fuzzy"} 1
+binocular_search_results_count{index="default",tenant="",type="fuzzy"} 1
+`), "binocular_search_results"); err != nil {
+		t.Errorf("unexpected metrics: %s", err)
+	}
+}
+
+func TestMetrics_Tenant(t *testing.T) {
+	metrics := New()
+	b := binocular.New(binocular.WithMetrics(metrics))
+	metrics.Observe(b)
+	acme := b.Tenant("acme")
+	if err := acme.AddWithID("1", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := acme.Search("rocket", binocular.DefaultIndex); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `
+# HELP binocular_documents Current number of documents.
+# TYPE binocular_documents gauge
+binocular_documents{tenant=""} 0
+binocular_documents{tenant="acme"} 1
+# HELP binocular_documents_added_total Number of documents added with a new id.
+# TYPE binocular_documents_added_total counter
+binocular_documents_added_total{tenant="acme"} 1
+# HELP binocular_searches_total Number of searches by index and type.
+# TYPE binocular_searches_total counter
+binocular_searches_total{index="default",tenant="acme",type="exact"} 1
+`
+	names := []string{"binocular_documents", "binocular_documents_added_total", "binocular_searches_total"}
+	if err := testutil.CollectAndCompare(metrics, strings.NewReader(expected), names...); err != nil {
+		t.Errorf("unexpected metrics: %s", err)
+	}
+
+	if err := b.DropTenant("acme"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := testutil.ToFloat64(metrics.documentsRemoved.WithLabelValues("acme")); n != 1 {
+		t.Errorf("expected 1 removed document of the dropped tenant, got %v", n)
+	}
+}
+
+func TestWithNamespace(t *testing.T) {
+	metrics := New(WithNamespace("search"))
+	metrics.DocumentsAdded(2)
+	expected := `
+# HELP search_documents_added_total Number of documents added with a new id.
+# TYPE search_documents_added_total counter
+search_documents_added_total{tenant=""} 2
+`
+	if err := testutil.CollectAndCompare(metrics, strings.NewReader(expected), "search_documents_added_total"); err != nil {
+		t.Errorf("unexpected metrics: %s", err)
+	}
+}
@@ -0,0 +1,99 @@
+package binocular
+
+import (
+	"sync"
+
+	"github.com/RoaringBitmap/roaring"
+)
+
+// refTable assigns dense document numbers to references so postings can be stored as bitmaps.
+// Released numbers are reused for new references, so a number must be removed from all bitmaps
+// before it is released. It is safe for concurrent use.
+type refTable struct {
+	mut  sync.RWMutex
+	nums map[string]uint32
+	refs []string
+	free []uint32
+}
+
+func newRefTable() *refTable {
+	return &refTable{
+		nums: make(map[string]uint32),
+	}
+}
+
+// num returns the number of the reference and assigns a new one if it has none yet.
+func (table *refTable) num(ref string) uint32 {
+	table.mut.RLock()
+	num, ok := table.nums[ref]
+	table.mut.RUnlock()
+	if ok {
+		return num
+	}
+	table.mut.Lock()
+	defer table.mut.Unlock()
+	if num, ok := table.nums[ref]; ok {
+		return num
+	}
+	if n := len(table.free); n > 0 {
+		num = table.free[n-1]
+		table.free = table.free[:n-1]
+		table.refs[num] = ref
+	} else {
+		num = uint32(len(table.refs))
+		table.refs = append(table.refs, ref)
+	}
+	table.nums[ref] = num
+	return num
+}
+
+// lookup returns the number of the reference and whether it has one.
+func (table *refTable) lookup(ref string) (uint32, bool) {
+	table.mut.RLock()
+	defer table.mut.RUnlock()
+	num, ok := table.nums[ref]
+	return num, ok
+}
+
+// ref returns the reference of the number or an empty string if it has been released.
+func (table *refTable) ref(num uint32) string {
+	table.mut.RLock()
+	defer table.mut.RUnlock()
+	return table.refs[num]
+}
+
+// release removes the reference from the table and frees its number for reuse.
+// The caller must have removed the number from all bitmaps.
+func (table *refTable) release(ref string) {
+	table.mut.Lock()
+	defer table.mut.Unlock()
+	num, ok := table.nums[ref]
+	if !ok {
+		return
+	}
+	delete(table.nums, ref)
+	table.refs[num] = ""
+	table.free = append(table.free, num)
+}
+
+// resolve returns the references of all numbers in the bitmap in ascending order.
+func (table *refTable) resolve(bitmap *roaring.Bitmap) []string {
+	refs := make([]string, 0, bitmap.GetCardinality())
+	table.each(bitmap, func(_ uint32, ref string) {
+		refs = append(refs, ref)
+	})
+	return refs
+}
+
+// each calls fn for all numbers in the bitmap in ascending order which have not been released.
+func (table *refTable) each(bitmap *roaring.Bitmap, fn func(num uint32, ref string)) {
+	table.mut.RLock()
+	defer table.mut.RUnlock()
+	it := bitmap.Iterator()
+	for it.HasNext() {
+		num := it.Next()
+		if ref := table.refs[num]; ref != "" {
+			fn(num, ref)
+		}
+	}
+}
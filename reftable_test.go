@@ -0,0 +1,40 @@
+package binocular
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+)
+
+func TestRefTable(t *testing.T) {
+	table := newRefTable()
+	if table.num("a") != 0 || table.num("b") != 1 || table.num("a") != 0 {
+		t.Error("numbers should be dense and stable")
+	}
+	num, ok := table.lookup("b")
+	if !ok || num != 1 {
+		t.Error("wrong number")
+	}
+	table.release("a")
+	if _, ok := table.lookup("a"); ok {
+		t.Error("released ref should not have a number")
+	}
+	refs := table.resolve(roaring.BitmapOf(0, 1))
+	if !reflect.DeepEqual(refs, []string{"b"}) {
+		t.Errorf("released ref should not be resolved: %v", refs)
+	}
+	if table.num("c") != 0 {
+		t.Error("released numbers should be reused")
+	}
+	if table.num("a") != 2 {
+		t.Error("new numbers should be assigned once no released number is left")
+	}
+	refs = table.resolve(roaring.BitmapOf(0, 1, 2))
+	if !reflect.DeepEqual(refs, []string{"c", "b", "a"}) {
+		t.Errorf("wrong refs: %v", refs)
+	}
+	if len(table.nums) != 3 || len(table.refs) != 3 {
+		t.Errorf("wrong size: %d", len(table.nums))
+	}
+}
@@ -0,0 +1,162 @@
+package binocular
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidSchema indicates that a Schema definition is not valid.
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// ErrUnknownField indicates that a document contains a tagged field which is not part of the Schema.
+var ErrUnknownField = errors.New("unknown field")
+
+// FieldType determines how the values of a Field are indexed.
+type FieldType int
+
+const (
+	// TextField values are split into words and analyzed according to the IndexOptions of the Field.
+	TextField FieldType = iota
+	// KeywordField values are indexed verbatim as a single term.
+	KeywordField
+	// NumericField values are numbers which are indexed verbatim like a KeywordField
+	// and can be aggregated into histograms and ranges. Struct fields with a number type
+	// are only indexed if their tag has the "numeric" option, e.g. `binocular:"price,numeric"`.
+	NumericField
+)
+
+// String returns the name of the FieldType.
+func (fieldType FieldType) String() string {
+	switch fieldType {
+	case TextField:
+		return "text"
+	case KeywordField:
+		return "keyword"
+	case NumericField:
+		return "numeric"
+	}
+	return fmt.Sprintf("FieldType(%d)", int(fieldType))
+}
+
+// returns the IndexOptions for an Index holding values of the FieldType.
+func (fieldType FieldType) options(options []IndexOption) []IndexOption {
+	if fieldType == TextField {
+		return options
+	}
+	return append(options[:len(options):len(options)], WithKeyword())
+}
+
+// Field describes how the values of a tagged struct field are indexed.
+// Name is the name used in the `binocular` tag, Index is the name of the Index
+// receiving the values and defaults to Name. Options configure the analyzer of the Index.
+type Field struct {
+	Name    string
+	Index   string
+	Type    FieldType
+	Options []IndexOption
+}
+
+// Schema is a validated set of Fields which can be registered on a Binocular instance.
+type Schema struct {
+	fields map[string]Field
+}
+
+// NewSchema validates the given Fields and creates a new Schema.
+// ErrInvalidSchema is returned if a Field has no name, a name is used more than once,
+// a FieldType is unknown or Fields sharing an Index have different FieldTypes.
+func NewSchema(fields ...Field) (*Schema, error) {
+	schema := &Schema{
+		fields: make(map[string]Field, len(fields)),
+	}
+	indexTypes := make(map[string]FieldType)
+	for _, field := range fields {
+		if field.Name == "" {
+			return nil, fmt.Errorf("%w: field without name", ErrInvalidSchema)
+		}
+		if _, ok := schema.fields[field.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate field %q", ErrInvalidSchema, field.Name)
+		}
+		if field.Type != TextField && field.Type != KeywordField && field.Type != NumericField {
+			return nil, fmt.Errorf("%w: field %q has unknown type %s", ErrInvalidSchema, field.Name, field.Type)
+		}
+		if field.Index == "" {
+			field.Index = field.Name
+		}
+		if t, ok := indexTypes[field.Index]; ok && t != field.Type {
+			return nil, fmt.Errorf("%w: index %q is used as %s and %s", ErrInvalidSchema, field.Index, t, field.Type)
+		}
+		indexTypes[field.Index] = field.Type
+		schema.fields[field.Name] = field
+	}
+	return schema, nil
+}
+
+// Field returns the Field with the given name and whether it exists.
+func (schema *Schema) Field(name string) (Field, bool) {
+	field, ok := schema.fields[name]
+	return field, ok
+}
+
+// Fields returns all Fields of the Schema sorted by name.
+func (schema *Schema) Fields() []Field {
+	fields := make([]Field, 0, len(schema.fields))
+	for _, field := range schema.fields {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+// WithSchema registers the Schema and creates an Index for each of its Fields.
+// Tagged fields which are not part of the Schema are added to an Index named after the tag.
+func WithSchema(schema *Schema) Option {
+	return func(binocular *Binocular) {
+		binocular.schema = schema
+		for _, field := range schema.fields {
+			binocular.indices[field.Index] = binocular.newIndex(field.Type.options(field.Options)...)
+		}
+	}
+}
+
+// WithStrictSchema registers the Schema like WithSchema but rejects documents
+// with tagged fields which are not part of the Schema with ErrUnknownField.
+func WithStrictSchema(schema *Schema) Option {
+	return func(binocular *Binocular) {
+		WithSchema(schema)(binocular)
+		binocular.strict = true
+	}
+}
+
+// Schema returns the Schema registered on the Binocular instance or nil if there is none.
+func (binocular *Binocular) Schema() *Schema {
+	return binocular.schema
+}
+
+// Indices returns the names of all indices of the Binocular instance in sorted order.
+func (binocular *Binocular) Indices() []string {
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
+	names := make([]string, 0, len(binocular.indices))
+	for name := range binocular.indices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolves the tag name and FieldType of a struct field to the name and FieldType of its Index.
+// ErrUnknownField is returned in strict mode if the Schema has no Field with the given name.
+func (binocular *Binocular) resolveField(name string, typ FieldType) (string, FieldType, error) {
+	if binocular.schema != nil {
+		if field, ok := binocular.schema.Field(name); ok {
+			return field.Index, field.Type, nil
+		}
+	}
+	if binocular.strict {
+		return "", typ, fmt.Errorf("%w: %s", ErrUnknownField, name)
+	}
+	return name, typ, nil
+}
@@ -0,0 +1,141 @@
+package binocular
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewSchema(t *testing.T) {
+	schema, err := NewSchema(
+		Field{Name: "title", Index: DefaultIndex, Options: []IndexOption{WithStemming()}},
+		Field{Name: "brand", Type: KeywordField},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	field, ok := schema.Field("brand")
+	if !ok {
+		t.Fatal("field brand should exist")
+	}
+	if field.Index != "brand" {
+		t.Errorf("expected index to default to field name, got %s", field.Index)
+	}
+	fields := schema.Fields()
+	if len(fields) != 2 || fields[0].Name != "brand" || fields[1].Name != "title" {
+		t.Error("fields should be sorted by name")
+	}
+}
+
+func TestNewSchema_Invalid(t *testing.T) {
+	testdata := []struct {
+		name   string
+		fields []Field
+	}{
+		{
+			"field without name",
+			[]Field{{Index: "idx"}},
+		},
+		{
+			"duplicate field",
+			[]Field{{Name: "title"}, {Name: "title", Index: "other"}},
+		},
+		{
+			"unknown type",
+			[]Field{{Name: "title", Type: FieldType(42)}},
+		},
+		{
+			"conflicting index types",
+			[]Field{{Name: "title", Index: "idx"}, {Name: "brand", Index: "idx", Type: KeywordField}},
+		},
+	}
+	for _, td := range testdata {
+		t.Run(td.name, func(t *testing.T) {
+			schema, err := NewSchema(td.fields...)
+			if !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("wrong error: %v", err)
+			}
+			if schema != nil {
+				t.Error("schema should be nil")
+			}
+		})
+	}
+}
+
+func TestWithSchema(t *testing.T) {
+	schema, err := NewSchema(
+		Field{Name: "title", Index: "titles"},
+		Field{Name: "brand", Type: KeywordField},
+		Field{Name: "year", Type: NumericField},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b := New(WithSchema(schema))
+	if b.Schema() != schema {
+		t.Error("wrong schema")
+	}
+	if !reflect.DeepEqual(b.Indices(), []string{"brand", DefaultIndex, "titles", "year"}) {
+		t.Errorf("wrong indices: %v", b.Indices())
+	}
+	if !b.indices["brand"].keyword || !b.indices["year"].keyword {
+		t.Error("brand and year should be keyword indices")
+	}
+	testdata := struct {
+		Title string `binocular:"title"`
+		Brand string `binocular:"brand"`
+		Color string `binocular:"color"`
+	}{
+		"The quick brown fox",
+		"Acme Corp",
+		"brown",
+	}
+	id, err := b.Add(testdata)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b.indices["titles"].Postings("quick")[0] != id {
+		t.Error("wrong id")
+	}
+	if b.indices["brand"].Postings("Acme Corp")[0] != id {
+		t.Error("wrong id")
+	}
+	if b.indices["color"].Postings("brown")[0] != id {
+		t.Error("unknown fields should be added to an index named after the tag")
+	}
+}
+
+func TestWithStrictSchema(t *testing.T) {
+	schema, err := NewSchema(Field{Name: "title"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b := New(WithStrictSchema(schema))
+	err = b.AddWithID("123", struct {
+		Title string `binocular:"title"`
+		Color string `binocular:"color"`
+	}{
+		"The quick brown fox",
+		"brown",
+	})
+	if !errors.Is(err, ErrUnknownField) {
+		t.Errorf("wrong error: %v", err)
+	}
+	if b.docs["123"] != nil {
+		t.Error("document should not exist")
+	}
+	if len(b.indices["title"].data) != 0 {
+		t.Error("index should be empty")
+	}
+	if b.indices["color"] != nil {
+		t.Error("index should not exist")
+	}
+	err = b.AddWithID("456", struct {
+		Title string `binocular:"title"`
+	}{
+		"The quick brown fox",
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,88 @@
+package binocular
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/RoaringBitmap/roaring"
+)
+
+// Hit is a found reference with the relevance score of the search.
+type Hit struct {
+	Ref   string
+	Score float64
+}
+
+// search evaluates the Query, restricts the matches to the filter if it is not nil and scores them.
+// The filter does not change the scores. The hits are returned in ascending order of their document numbers.
+func (index *Index) search(ctx context.Context, query Query, filter *roaring.Bitmap) ([]Hit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	index.rLock()
+	defer index.mut.RUnlock()
+	result, terms, err := index.evaluate(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		result.And(filter)
+	}
+	scores := index.score(terms, result)
+	hits := make([]Hit, 0, result.GetCardinality())
+	index.refs.each(result, func(num uint32, ref string) {
+		hits = append(hits, Hit{Ref: ref, Score: scores[num]})
+	})
+	index.searched(query, start, len(hits))
+	return hits, nil
+}
+
+// score sums up the tf-idf weight of every matched term for the document numbers in the bitmap.
+// The term frequency is 1 unless WithFrequencies is enabled. The caller must hold the read lock.
+func (index *Index) score(terms []string, bitmap *roaring.Bitmap) map[uint32]float64 {
+	scores := make(map[uint32]float64, bitmap.GetCardinality())
+	docs := float64(index.docs.GetCardinality())
+	for _, term := range terms {
+		postings, ok := index.data[term]
+		if !ok {
+			continue
+		}
+		idf := index.idf(docs, postings)
+		it := roaring.And(postings, bitmap).Iterator()
+		for it.HasNext() {
+			num := it.Next()
+			scores[num] += float64(index.tf(term, num)) * idf
+		}
+	}
+	return scores
+}
+
+// returns the inverse document frequency of a term with the given postings.
+func (index *Index) idf(docs float64, postings *roaring.Bitmap) float64 {
+	return math.Log(1 + docs/float64(postings.GetCardinality()))
+}
+
+// returns the frequency of the term for the document number. The caller must hold the read lock.
+func (index *Index) tf(term string, num uint32) uint32 {
+	if !index.frequencies {
+		return 1
+	}
+	return index.freqs[term][num]
+}
+
+// Hits returns the found references with their relevance score, the most relevant first.
+// Hits with the same score are sorted by their reference.
+func (searchResult *SearchResult) Hits() []Hit {
+	hits := make([]Hit, len(searchResult.hits))
+	copy(hits, searchResult.hits)
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Ref < hits[j].Ref
+	})
+	return hits
+}
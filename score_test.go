@@ -0,0 +1,94 @@
+package binocular
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSearchResult_Hits(t *testing.T) {
+	b := New(WithDefaultIndex(DefaultIndex, WithFrequencies()))
+	docs := map[string]string{
+		"once":  "rocket launch",
+		"twice": "rocket rocket launch",
+		"other": "anvil launch",
+	}
+	for id, doc := range docs {
+		if err := b.AddWithID(id, doc); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	result, err := b.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	hits := result.Hits()
+	if len(hits) != 2 || hits[0].Ref != "twice" || hits[1].Ref != "once" {
+		t.Fatalf("more frequent terms should score higher: %v", hits)
+	}
+	if hits[0].Score != 2*hits[1].Score {
+		t.Errorf("score should grow with the term frequency: %v", hits)
+	}
+
+	result, err = b.Query(Query{Any: []string{"rocket", "launch"}}, DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	scores := make(map[string]float64)
+	for _, hit := range result.Hits() {
+		scores[hit.Ref] = hit.Score
+	}
+	if !(scores["once"] > scores["other"]) {
+		t.Errorf("rare terms should score higher than common ones: %v", scores)
+	}
+}
+
+func TestSearchResult_Hits_IndexDocs(t *testing.T) {
+	type doc struct {
+		Text  string `binocular:"default"`
+		Title string `binocular:"title"`
+	}
+	b := New(WithIndex("title"))
+	if err := b.AddWithID("1", doc{"rocket launch", "rocket"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, id := range []string{"2", "3", "4"} {
+		if err := b.AddWithID(id, doc{Text: "anvil launch"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	result, err := b.Search("rocket", "title")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// the title index holds one document, the other indices do not count
+	if hits := result.Hits(); len(hits) != 1 || hits[0].Score != math.Log(2) {
+		t.Errorf("idf should only count the documents of the index: %v", hits)
+	}
+	explanation, err := b.Explain(Query{All: []string{"rocket"}}, "title", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if matches := explanation.Words[0].Matches; len(matches) != 1 || matches[0].Docs != 1 {
+		t.Errorf("wrong matches: %+v", matches)
+	}
+}
+
+func TestSearchResult_Hits_Order(t *testing.T) {
+	b := New()
+	for _, id := range []string{"b", "a", "c"} {
+		if err := b.AddWithID(id, "rocket"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	result, err := b.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	hits := result.Hits()
+	for i, ref := range []string{"a", "b", "c"} {
+		if hits[i].Ref != ref {
+			t.Errorf("hits with equal scores should be sorted by ref: %v", hits)
+		}
+	}
+}
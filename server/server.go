@@ -0,0 +1,343 @@
+// Package server exposes a Binocular instance over a REST API using JSON.
+//
+// The following routes are supported:
+//
+//	POST   /docs                  add a document with a generated id
+//	POST   /docs/{id}             add a document with the given id
+//	PUT    /docs/{id}             add or replace the document with the given id
+//	GET    /docs/{id}             get the document with the given id
+//	DELETE /docs/{id}             remove the document with the given id
+//	GET    /indices               list all indices
+//	GET    /indices/{name}/search search the index, see Server.search for the parameters
+//	GET    /stats                 get the statistics of the Binocular instance
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mycreepy/go-binocular"
+)
+
+// DefaultMaxBodyBytes is the default maximum size of a request body.
+const DefaultMaxBodyBytes = 1 << 20
+
+// Server is a http.Handler serving the REST API of a Binocular instance.
+// Documents are stored as json.RawMessage so field mappings of the Binocular instance are applied.
+type Server struct {
+	binocular    *binocular.Binocular
+	maxBodyBytes int64
+}
+
+// Option can alter the behavior of a Server.
+type Option func(server *Server)
+
+// WithMaxBodyBytes limits the size of request bodies to the given number of bytes.
+func WithMaxBodyBytes(n int64) Option {
+	return func(server *Server) {
+		server.maxBodyBytes = n
+	}
+}
+
+// New creates a new Server for the given Binocular instance with the given Options.
+func New(b *binocular.Binocular, options ...Option) *Server {
+	server := &Server{
+		binocular:    b,
+		maxBodyBytes: DefaultMaxBodyBytes,
+	}
+	for _, opt := range options {
+		opt(server)
+	}
+	return server
+}
+
+// errorResponse is the body of every failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// addResponse is the body of a successful POST /docs request.
+type addResponse struct {
+	ID string `json:"id"`
+}
+
+// searchResponse is the body of a successful search request.
+// Docs is only set if the documents have been requested.
+type searchResponse struct {
+	Refs []string          `json:"refs"`
+	Hits []hit             `json:"hits"`
+	Docs []json.RawMessage `json:"docs,omitempty"`
+}
+
+// hit is a found reference with its relevance score, the most relevant first.
+type hit struct {
+	Ref   string  `json:"ref"`
+	Score float64 `json:"score"`
+}
+
+// statsResponse is the body of a successful GET /stats request.
+type statsResponse struct {
+	Docs    int                   `json:"docs"`
+	Bytes   int64                 `json:"bytes"`
+	Limit   int64                 `json:"limit"`
+	Indices map[string]indexStats `json:"indices"`
+}
+
+// indexStats are the statistics of a single Index.
+type indexStats struct {
+	Terms    int   `json:"terms"`
+	Postings int   `json:"postings"`
+	Docs     int   `json:"docs"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// ServeHTTP routes the request to its handler.
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "docs":
+		server.route(w, r, map[string]http.HandlerFunc{
+			http.MethodPost: server.add,
+		})
+	case len(parts) == 2 && parts[0] == "docs" && parts[1] != "":
+		id := parts[1]
+		server.route(w, r, map[string]http.HandlerFunc{
+			http.MethodPost:   func(w http.ResponseWriter, r *http.Request) { server.addWithID(w, r, id) },
+			http.MethodPut:    func(w http.ResponseWriter, r *http.Request) { server.upsert(w, r, id) },
+			http.MethodGet:    func(w http.ResponseWriter, r *http.Request) { server.get(w, id) },
+			http.MethodDelete: func(w http.ResponseWriter, r *http.Request) { server.remove(w, id) },
+		})
+	case len(parts) == 1 && parts[0] == "indices":
+		server.route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: server.indices,
+		})
+	case len(parts) == 3 && parts[0] == "indices" && parts[2] == "search":
+		index := parts[1]
+		server.route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) { server.search(w, r, index) },
+		})
+	case len(parts) == 1 && parts[0] == "stats":
+		server.route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: server.stats,
+		})
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// calls the handler of the request method or responds with 405 Method Not Allowed.
+func (server *Server) route(w http.ResponseWriter, r *http.Request, handlers map[string]http.HandlerFunc) {
+	handler, ok := handlers[r.Method]
+	if !ok {
+		methods := make([]string, 0, len(handlers))
+		for method := range handlers {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	handler(w, r)
+}
+
+func (server *Server) add(w http.ResponseWriter, r *http.Request) {
+	doc, err := server.readDocument(w, r)
+	if err != nil {
+		writeError(w, bodyStatusCode(err), err)
+		return
+	}
+	id, err := server.binocular.Add(doc)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, addResponse{ID: id})
+}
+
+func (server *Server) addWithID(w http.ResponseWriter, r *http.Request, id string) {
+	doc, err := server.readDocument(w, r)
+	if err != nil {
+		writeError(w, bodyStatusCode(err), err)
+		return
+	}
+	if err := server.binocular.AddWithID(id, doc); err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, addResponse{ID: id})
+}
+
+func (server *Server) upsert(w http.ResponseWriter, r *http.Request, id string) {
+	doc, err := server.readDocument(w, r)
+	if err != nil {
+		writeError(w, bodyStatusCode(err), err)
+		return
+	}
+	if err := server.binocular.Upsert(id, doc); err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (server *Server) get(w http.ResponseWriter, id string) {
+	data, err := server.binocular.Get(id)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	doc, err := marshalDocument(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func (server *Server) remove(w http.ResponseWriter, id string) {
+	if err := server.binocular.Remove(id); err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (server *Server) indices(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, server.binocular.Indices())
+}
+
+func (server *Server) stats(w http.ResponseWriter, _ *http.Request) {
+	stats := server.binocular.Stats()
+	response := statsResponse{
+		Docs:    stats.Docs,
+		Bytes:   stats.Bytes,
+		Limit:   stats.Limit,
+		Indices: make(map[string]indexStats, len(stats.Indices)),
+	}
+	for name, s := range stats.Indices {
+		response.Indices[name] = indexStats(s)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// search handles the query parameters
+//
+//	q        the word to search for (required)
+//	distance the Levenshtein distance for a fuzzy search (default 0)
+//	filter   a filter as index:value, may be repeated
+//	docs     include the documents in the response if true
+func (server *Server) search(w http.ResponseWriter, r *http.Request, index string) {
+	query := r.URL.Query()
+	word := query.Get("q")
+	if word == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing query parameter q"))
+		return
+	}
+	distance := 0
+	if d := query.Get("distance"); d != "" {
+		var err error
+		distance, err = strconv.Atoi(d)
+		if err != nil || distance < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid distance"))
+			return
+		}
+	}
+	filters := make([]binocular.Filter, 0, len(query["filter"]))
+	for _, f := range query["filter"] {
+		name, value, ok := strings.Cut(f, ":")
+		if !ok {
+			writeError(w, http.StatusBadRequest, errors.New("invalid filter, expected index:value"))
+			return
+		}
+		filters = append(filters, binocular.Filter{Index: name, Value: value})
+	}
+	result, err := server.binocular.FuzzySearchContext(r.Context(), word, index, distance, filters...)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	response := searchResponse{
+		Refs: result.Refs(),
+		Hits: make([]hit, 0, len(result.Refs())),
+	}
+	for _, h := range result.Hits() {
+		response.Hits = append(response.Hits, hit(h))
+	}
+	if withDocs, _ := strconv.ParseBool(query.Get("docs")); withDocs {
+		data, err := result.Collect()
+		if err != nil {
+			writeError(w, statusCode(err), err)
+			return
+		}
+		response.Docs = make([]json.RawMessage, len(data))
+		for i, d := range data {
+			if response.Docs[i], err = marshalDocument(d); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// reads the request body as JSON document.
+func (server *Server) readDocument(w http.ResponseWriter, r *http.Request) (json.RawMessage, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, server.maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(body) {
+		return nil, errors.New("invalid JSON document")
+	}
+	return body, nil
+}
+
+// maps the errors of reading a request body to a status code.
+func bodyStatusCode(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// returns the data as JSON, json.RawMessage is returned as it is.
+func marshalDocument(data interface{}) (json.RawMessage, error) {
+	if raw, ok := data.(json.RawMessage); ok {
+		return raw, nil
+	}
+	return json.Marshal(data)
+}
+
+// maps the errors of a Binocular instance to a status code.
+func statusCode(err error) int {
+	var limitErr *binocular.MemoryLimitError
+	switch {
+	case errors.Is(err, binocular.ErrIndexNotFound), errors.Is(err, binocular.ErrRefNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, binocular.ErrRefExists):
+		return http.StatusConflict
+	case errors.Is(err, binocular.ErrUnknownField):
+		return http.StatusBadRequest
+	case errors.As(err, &limitErr):
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
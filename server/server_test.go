@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mycreepy/go-binocular"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	b := binocular.New(
+		binocular.WithFieldMapping("title", binocular.DefaultIndex),
+		binocular.WithFieldMapping("status", "status"),
+		binocular.WithIndex("status", binocular.WithKeyword()),
+		binocular.WithUniqueIDs(),
+	)
+	docs := []string{
+		`{"title": "Houston we have a problem", "status": "published"}`,
+		`{"title": "Houston we had a problem", "status": "draft"}`,
+	}
+	for i, doc := range docs {
+		if err := b.AddWithID(strconv.Itoa(i+1), json.RawMessage(doc)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	server := httptest.NewServer(New(b, WithMaxBodyBytes(128)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{"get", http.MethodGet, "/docs/1", "", http.StatusOK, `{"title": "Houston we have a problem", "status": "published"}`},
+		{"get not found", http.MethodGet, "/docs/3", "", http.StatusNotFound, `{"error":"ref not found"}`},
+		{"add with id", http.MethodPost, "/docs/3", `{"title": "rocket"}`, http.StatusCreated, `{"id":"3"}`},
+		{"add existing id", http.MethodPost, "/docs/1", `{"title": "rocket"}`, http.StatusConflict, `{"error":"ref already exists"}`},
+		{"add invalid json", http.MethodPost, "/docs/3", `{"title":`, http.StatusBadRequest, `{"error":"invalid JSON document"}`},
+		{"add too large", http.MethodPost, "/docs/3", `"` + strings.Repeat("a", 128) + `"`, http.StatusRequestEntityTooLarge, `{"error":"http: request body too large"}`},
+		{"upsert too large", http.MethodPut, "/docs/3", `"` + strings.Repeat("a", 128) + `"`, http.StatusRequestEntityTooLarge, `{"error":"http: request body too large"}`},
+		{"upsert", http.MethodPut, "/docs/1", `{"title": "rocket"}`, http.StatusNoContent, ``},
+		{"delete", http.MethodDelete, "/docs/1", "", http.StatusNoContent, ``},
+		{"delete not found", http.MethodDelete, "/docs/3", "", http.StatusNotFound, `{"error":"ref not found"}`},
+		{"indices", http.MethodGet, "/indices", "", http.StatusOK, `["default","status"]`},
+		{"search", http.MethodGet, "/indices/default/search?q=houston&filter=status:published", "", http.StatusOK, `{"refs":["1"],"hits":[{"ref":"1","score":0.6931471805599453}]}`},
+		{"search with docs", http.MethodGet, "/indices/status/search?q=draft&docs=true", "", http.StatusOK, `{"refs":["2"],"hits":[{"ref":"2","score":1.0986122886681096}],"docs":[{"title":"Houston we had a problem","status":"draft"}]}`},
+		{"fuzzy search", http.MethodGet, "/indices/default/search?q=hust&distance=3", "", http.StatusOK, `{"refs":["1","2"],"hits":[{"ref":"1","score":0.6931471805599453},{"ref":"2","score":0.6931471805599453}]}`},
+		{"search index not found", http.MethodGet, "/indices/unknown/search?q=houston", "", http.StatusNotFound, `{"error":"index not found"}`},
+		{"search filter index not found", http.MethodGet, "/indices/default/search?q=houston&filter=unknown:x", "", http.StatusNotFound, `{"error":"index not found"}`},
+		{"search without query", http.MethodGet, "/indices/default/search", "", http.StatusBadRequest, `{"error":"missing query parameter q"}`},
+		{"search invalid distance", http.MethodGet, "/indices/default/search?q=houston&distance=x", "", http.StatusBadRequest, `{"error":"invalid distance"}`},
+		{"search invalid filter", http.MethodGet, "/indices/default/search?q=houston&filter=x", "", http.StatusBadRequest, `{"error":"invalid filter, expected index:value"}`},
+		{"method not allowed", http.MethodPatch, "/docs/1", "", http.StatusMethodNotAllowed, `{"error":"method not allowed"}`},
+		{"unknown route", http.MethodGet, "/unknown", "", http.StatusNotFound, `{"error":"not found"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, res.StatusCode)
+			}
+			var got, want interface{}
+			if tt.want == "" {
+				return
+			}
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestServer_Add(t *testing.T) {
+	server := newTestServer(t)
+	res, err := http.Post(server.URL+"/docs", "application/json", strings.NewReader(`{"title": "rocket"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("wrong status: %d", res.StatusCode)
+	}
+	var body addResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	res, err = http.Get(server.URL + "/indices/default/search?q=rocket")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+	var result searchResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(result.Refs, []string{body.ID}) {
+		t.Errorf("added document should be found: %v", result.Refs)
+	}
+}
+
+func TestServer_Stats(t *testing.T) {
+	server := newTestServer(t)
+	res, err := http.Get(server.URL + "/stats")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+	var stats statsResponse
+	if err := json.NewDecoder(res.Body).Decode(&stats); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stats.Docs != 2 || stats.Indices["status"].Terms != 2 {
+		t.Errorf("wrong stats: %+v", stats)
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{binocular.ErrIndexNotFound, http.StatusNotFound},
+		{binocular.ErrRefNotFound, http.StatusNotFound},
+		{binocular.ErrRefExists, http.StatusConflict},
+		{binocular.ErrUnknownField, http.StatusBadRequest},
+		{&binocular.MemoryLimitError{}, http.StatusInsufficientStorage},
+		{binocular.ErrSnapshotVersion, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if status := statusCode(tt.err); status != tt.status {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.status, status)
+		}
+	}
+}
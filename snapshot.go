@@ -0,0 +1,197 @@
+package binocular
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// snapshotVersion is the version of the snapshot format written by Snapshot.
+const snapshotVersion = 1
+
+// ErrSnapshotVersion indicates that a snapshot has been written in an unsupported format.
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+func init() {
+	// register the document types which are supported out of the box
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register(json.RawMessage{})
+}
+
+// snapshotHeader is written at the start of every snapshot.
+type snapshotHeader struct {
+	Version      int
+	DefaultIndex string
+	Docs         int
+	Indices      int
+}
+
+// snapshotDocument is a single document with its id.
+type snapshotDocument struct {
+	ID            string
+	Data          interface{}
+	RecordLocator map[string][]string
+	Expires       time.Time
+}
+
+// snapshotIndex is a single Index with its name, options and postings.
+type snapshotIndex struct {
+	Name           string
+	Stemming       bool
+	KeepStopWords  bool
+	KeepShortWords bool
+	Keyword        bool
+	Frequencies    bool
+	// Postings holds a reference once per occurrence if Frequencies is enabled.
+	Postings map[string][]string
+}
+
+// SnapshotContext writes all documents and indices of the Binocular instance to w using encoding/gob.
+// Custom types used as document data have to be registered with gob.Register.
+// It stops writing and returns ctx.Err() once the context is done.
+func (binocular *Binocular) SnapshotContext(ctx context.Context, w io.Writer) error {
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
+	enc := gob.NewEncoder(w)
+	err := enc.Encode(snapshotHeader{
+		Version:      snapshotVersion,
+		DefaultIndex: binocular.DefaultIndex,
+		Docs:         len(binocular.docs),
+		Indices:      len(binocular.indices),
+	})
+	if err != nil {
+		return err
+	}
+	i := 0
+	for id, doc := range binocular.docs {
+		if i++; i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		err := enc.Encode(snapshotDocument{
+			ID:            id,
+			Data:          doc.Data,
+			RecordLocator: doc.recordLocator,
+			Expires:       doc.expires,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for name, index := range binocular.indices {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(index.snapshot(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreContext replaces all documents and indices of the Binocular instance with the ones read from the snapshot
+// written by SnapshotContext. Options like field mappings or a Schema are not part of a snapshot and are kept.
+// ErrSnapshotVersion is returned if the snapshot has been written in an unsupported format.
+// It stops reading and returns ctx.Err() once the context is done, the Binocular instance is left unchanged in that case.
+func (binocular *Binocular) RestoreContext(ctx context.Context, r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+	if header.Version != snapshotVersion {
+		return ErrSnapshotVersion
+	}
+	docs := make(map[string]*document, header.Docs)
+	for i := 0; i < header.Docs; i++ {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		var doc snapshotDocument
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+		docs[doc.ID] = &document{
+			Data:          doc.Data,
+			recordLocator: doc.RecordLocator,
+			expires:       doc.Expires,
+		}
+	}
+	refs := newRefTable()
+	indices := make(map[string]*Index, header.Indices)
+	for i := 0; i < header.Indices; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var index snapshotIndex
+		if err := dec.Decode(&index); err != nil {
+			return err
+		}
+		indices[index.Name] = restoreIndex(index, refs)
+	}
+
+	binocular.mut.Lock()
+	defer binocular.mut.Unlock()
+	binocular.DefaultIndex = header.DefaultIndex
+	binocular.docs = docs
+	for name, index := range indices {
+		binocular.instrument(name, index)
+	}
+	binocular.indices = indices
+	binocular.refs = refs
+	binocular.filters = newFilterCache()
+	binocular.retrack()
+	return nil
+}
+
+// snapshot returns the options and postings of the Index.
+func (index *Index) snapshot(name string) snapshotIndex {
+	index.rLock()
+	defer index.mut.RUnlock()
+	postings := make(map[string][]string, len(index.data))
+	for term, bitmap := range index.data {
+		refs := index.refs.resolve(bitmap)
+		if index.frequencies {
+			refs = make([]string, 0, len(refs))
+			for num, freq := range index.freqs[term] {
+				ref := index.refs.ref(num)
+				for i := uint32(0); i < freq; i++ {
+					refs = append(refs, ref)
+				}
+			}
+		}
+		postings[term] = refs
+	}
+	return snapshotIndex{
+		Name:           name,
+		Stemming:       index.stemming,
+		KeepStopWords:  index.keepStopWords,
+		KeepShortWords: index.keepShortWords,
+		Keyword:        index.keyword,
+		Frequencies:    index.frequencies,
+		Postings:       postings,
+	}
+}
+
+// creates a new Index from the options and postings of the snapshot using the given document numbers.
+func restoreIndex(snapshot snapshotIndex, refs *refTable) *Index {
+	index := NewIndex()
+	if snapshot.Frequencies {
+		WithFrequencies()(index)
+	}
+	index.refs = refs
+	index.sharedRefs = true
+	index.stemming = snapshot.Stemming
+	index.keepStopWords = snapshot.KeepStopWords
+	index.keepShortWords = snapshot.KeepShortWords
+	index.keyword = snapshot.Keyword
+	index.addPostings(snapshot.Postings)
+	return index
+}
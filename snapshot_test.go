@@ -0,0 +1,104 @@
+package binocular
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+type snapshotTestDoc struct {
+	Title string `binocular:"title"`
+}
+
+func TestBinocular_SnapshotAndRestore(t *testing.T) {
+	gob.Register(snapshotTestDoc{})
+	b := New(WithIndex("title", WithStemming(), WithFrequencies()), WithFieldMapping("author", "author"))
+	_ = b.AddWithID("123", "Always look on the bright side of life")
+	_ = b.AddWithID("456", map[string]interface{}{"author": "Jim Lovell"})
+	_ = b.AddWithID("789", json.RawMessage(`{"author": "Eric Idle"}`))
+	_ = b.AddWithID("abc", snapshotTestDoc{"There are too many cats, cats everywhere!"})
+	var buf bytes.Buffer
+	err := b.SnapshotContext(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	restored := New()
+	err = restored.RestoreContext(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(restored.docs) != 4 {
+		t.Errorf("expected 4 documents, got %d", len(restored.docs))
+	}
+	if !restored.indices["title"].stemming || !restored.indices["title"].frequencies {
+		t.Error("index options should be restored")
+	}
+	if restored.indices["title"].Frequency("cats", "abc") != 2 {
+		t.Error("frequencies should be restored")
+	}
+	result, err := restored.Search("cat", "title")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 1 || result.Refs()[0] != "abc" {
+		t.Error("wrong result")
+	}
+	result, err = restored.Search("idle", "author")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	data, err := result.Collect()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(data[0].(json.RawMessage)) != `{"author": "Eric Idle"}` {
+		t.Error("wrong data")
+	}
+	err = restored.Remove("123")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(restored.indices[DefaultIndex].Postings("bright")) != 0 {
+		t.Error("record locator should be restored")
+	}
+}
+
+func TestBinocular_Restore_ErrSnapshotVersion(t *testing.T) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(snapshotHeader{Version: snapshotVersion + 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b := New()
+	err = b.RestoreContext(context.Background(), &buf)
+	if err != ErrSnapshotVersion {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestBinocular_SnapshotContext_Cancelled(t *testing.T) {
+	b := New()
+	_ = b.AddWithID("123", "Lorem ipsum")
+	var buf bytes.Buffer
+	err := b.SnapshotContext(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = b.SnapshotContext(ctx, &bytes.Buffer{})
+	if err != context.Canceled {
+		t.Errorf("wrong error: %v", err)
+	}
+	restored := New()
+	err = restored.RestoreContext(ctx, &buf)
+	if err != context.Canceled {
+		t.Errorf("wrong error: %v", err)
+	}
+	if len(restored.docs) != 0 {
+		t.Error("binocular should be unchanged")
+	}
+}
@@ -0,0 +1,146 @@
+package binocular
+
+import (
+	"sort"
+)
+
+// IndexStats holds statistics about an Index.
+type IndexStats struct {
+	// Terms is the number of distinct terms.
+	Terms int
+	// Postings is the number of references stored for all terms.
+	Postings int
+	// Docs is the number of distinct references.
+	Docs int
+	// Bytes is the estimated memory usage.
+	Bytes int64
+}
+
+// Stats returns statistics about the Index.
+func (index *Index) Stats() IndexStats {
+	index.rLock()
+	defer index.mut.RUnlock()
+	stats := IndexStats{
+		Terms: len(index.data),
+		Docs:  int(index.docs.GetCardinality()),
+		Bytes: int64(index.docs.GetSizeInBytes()),
+	}
+	for term, bitmap := range index.data {
+		stats.Postings += int(bitmap.GetCardinality())
+		stats.Bytes += mapEntryBytes + stringHeaderBytes + int64(len(term)) + int64(bitmap.GetSizeInBytes())
+	}
+	for _, freqs := range index.freqs {
+		stats.Bytes += mapEntryBytes + int64(len(freqs))*mapEntryBytes
+	}
+	return stats
+}
+
+// Stats holds statistics about a Binocular instance.
+type Stats struct {
+	// Docs is the number of documents.
+	Docs int
+	// Bytes is the estimated memory usage of the documents including their postings.
+	Bytes int64
+	// Limit is the configured memory limit in bytes or 0 if there is none.
+	Limit int64
+	// Indices holds the statistics of each Index by name.
+	Indices map[string]IndexStats
+}
+
+// Stats returns statistics about the Binocular instance and its indices.
+func (binocular *Binocular) Stats() Stats {
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
+	stats := Stats{
+		Docs:    len(binocular.docs),
+		Bytes:   binocular.usage,
+		Limit:   binocular.memoryLimit,
+		Indices: make(map[string]IndexStats, len(binocular.indices)),
+	}
+	for name, index := range binocular.indices {
+		stats.Indices[name] = index.Stats()
+	}
+	return stats
+}
+
+// TermStats holds the document frequency of a term.
+type TermStats struct {
+	Term    string
+	DocFreq int
+}
+
+// DocFrequency returns the number of references the given word is stored for.
+func (index *Index) DocFrequency(word string) int {
+	term := index.normalize(word)
+	index.rLock()
+	defer index.mut.RUnlock()
+	bitmap, ok := index.data[term]
+	if !ok {
+		return 0
+	}
+	return int(bitmap.GetCardinality())
+}
+
+// VocabularySize returns the number of distinct terms.
+func (index *Index) VocabularySize() int {
+	index.rLock()
+	defer index.mut.RUnlock()
+	return len(index.data)
+}
+
+// DocCount returns the number of distinct references.
+func (index *Index) DocCount() int {
+	index.rLock()
+	defer index.mut.RUnlock()
+	return int(index.docs.GetCardinality())
+}
+
+// Terms calls fn for every term in lexical order until fn returns false.
+// The terms are collected before fn is called, so fn may modify the Index.
+func (index *Index) Terms(fn func(term string, docFreq int) bool) {
+	for _, ts := range index.termStats() {
+		if !fn(ts.Term, ts.DocFreq) {
+			return
+		}
+	}
+}
+
+// TopTerms returns up to n terms with the highest document frequency.
+// Terms with the same document frequency are sorted lexically.
+func (index *Index) TopTerms(n int) []TermStats {
+	terms := index.termStats()
+	sort.SliceStable(terms, func(i, j int) bool {
+		return terms[i].DocFreq > terms[j].DocFreq
+	})
+	if n >= 0 && n < len(terms) {
+		terms = terms[:n]
+	}
+	return terms
+}
+
+// returns the document frequency of all terms in lexical order.
+func (index *Index) termStats() []TermStats {
+	index.rLock()
+	terms := make([]TermStats, 0, len(index.data))
+	for term, bitmap := range index.data {
+		terms = append(terms, TermStats{Term: term, DocFreq: int(bitmap.GetCardinality())})
+	}
+	index.mut.RUnlock()
+	sort.Slice(terms, func(i, j int) bool {
+		return terms[i].Term < terms[j].Term
+	})
+	return terms
+}
+
+// Index returns the Index with the given name for introspection.
+// Data should only be added to the Index through the Binocular instance.
+// ErrIndexNotFound is returned if the given index does not exist.
+func (binocular *Binocular) Index(name string) (*Index, error) {
+	binocular.mut.RLock()
+	defer binocular.mut.RUnlock()
+	index, ok := binocular.indices[name]
+	if !ok {
+		return nil, ErrIndexNotFound
+	}
+	return index, nil
+}
@@ -0,0 +1,116 @@
+package binocular
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndex_Stats(t *testing.T) {
+	index := NewIndex()
+	index.Add("Always look on the bright side of life", "1")
+	index.Add("The meaning of life", "2")
+	stats := index.Stats()
+	if stats.Terms != 6 {
+		t.Errorf("expected 6 terms, got %d", stats.Terms)
+	}
+	if stats.Postings != 7 {
+		t.Errorf("expected 7 postings, got %d", stats.Postings)
+	}
+	if stats.Docs != 2 {
+		t.Errorf("expected 2 docs, got %d", stats.Docs)
+	}
+	if stats.Bytes <= 0 {
+		t.Error("bytes should be estimated")
+	}
+}
+
+func TestBinocular_Stats(t *testing.T) {
+	b := New(WithMemoryLimit(1<<20, RejectOnLimit))
+	_ = b.AddWithID("1", "Always look on the bright side of life")
+	_ = b.AddWithID("2", struct {
+		Title string `binocular:"title"`
+	}{
+		"The meaning of life",
+	})
+	stats := b.Stats()
+	if stats.Docs != 2 {
+		t.Errorf("expected 2 docs, got %d", stats.Docs)
+	}
+	if stats.Limit != 1<<20 {
+		t.Errorf("wrong limit: %d", stats.Limit)
+	}
+	if stats.Indices[DefaultIndex].Docs != 1 || stats.Indices["title"].Docs != 1 {
+		t.Error("wrong index stats")
+	}
+	bytes := stats.Bytes
+	if bytes <= 0 {
+		t.Error("bytes should be estimated")
+	}
+	_ = b.Remove("1")
+	_ = b.Remove("2")
+	if b.Stats().Bytes != 0 {
+		t.Errorf("bytes should be 0 after removing all documents, got %d", b.Stats().Bytes)
+	}
+}
+
+func TestIndex_Introspection(t *testing.T) {
+	index := NewIndex(WithStemming())
+	index.Add("cats and dogs", "1")
+	index.Add("more cats", "2")
+	index.Add("cats, dogs and birds", "3")
+	if index.DocFrequency("cats") != 3 {
+		t.Errorf("expected doc frequency 3, got %d", index.DocFrequency("cats"))
+	}
+	if index.DocFrequency("dog") != 2 {
+		t.Errorf("expected doc frequency 2, got %d", index.DocFrequency("dog"))
+	}
+	if index.DocFrequency("fish") != 0 {
+		t.Error("expected doc frequency 0")
+	}
+	if index.VocabularySize() != 5 {
+		t.Errorf("expected vocabulary size 5, got %d", index.VocabularySize())
+	}
+	if index.DocCount() != 3 {
+		t.Errorf("expected doc count 3, got %d", index.DocCount())
+	}
+	index.Remove("2")
+	if index.DocCount() != 2 || index.Stats().Docs != index.DocCount() {
+		t.Errorf("stats should agree with the doc count: %d, %d", index.Stats().Docs, index.DocCount())
+	}
+	index.Add("more cats", "2")
+	top := index.TopTerms(3)
+	expected := []TermStats{{"cat", 3}, {"and", 2}, {"dog", 2}}
+	if !reflect.DeepEqual(top, expected) {
+		t.Errorf("expected %v, got %v", expected, top)
+	}
+	if len(index.TopTerms(-1)) != 5 {
+		t.Error("negative n should return all terms")
+	}
+	terms := make([]string, 0)
+	index.Terms(func(term string, docFreq int) bool {
+		terms = append(terms, term)
+		return len(terms) < 2
+	})
+	if !reflect.DeepEqual(terms, []string{"and", "bird"}) {
+		t.Errorf("wrong terms: %v", terms)
+	}
+}
+
+func TestBinocular_Index(t *testing.T) {
+	b := New()
+	_ = b.AddWithID("1", "Lorem ipsum")
+	index, err := b.Index(DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if index.DocCount() != 1 {
+		t.Error("wrong doc count")
+	}
+	index, err = b.Index("unknown_idx")
+	if err != ErrIndexNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if index != nil {
+		t.Error("index should be nil")
+	}
+}
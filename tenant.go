@@ -0,0 +1,111 @@
+package binocular
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrTenantNotFound indicates that the given tenant does not exist.
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// Tenant returns the Binocular instance of the tenant with the given name and creates it on first use.
+// Each tenant has its own documents, indices and record locators and is created with the configuration of
+// the Binocular instance it belongs to: the indices created by Options, the Schema, field mappings, unique ids,
+// batch workers, the expiry interval and the tracer provider, and limits like WithMemoryLimit and WithCapacity
+// which apply per tenant. Hooks, subscriptions and Metrics of the instance receive the events and measurements
+// of its tenants, whereas the eviction callback is not inherited.
+// References of one tenant are never found by searches of another tenant or of the parent instance.
+// SnapshotContext and RestoreContext only cover the instance they are called on, not its tenants.
+// Once the instance has been closed, a new closed tenant is returned which is not registered.
+func (binocular *Binocular) Tenant(name string) *Binocular {
+	binocular.tenantMut.Lock()
+	defer binocular.tenantMut.Unlock()
+	tenant, ok := binocular.tenants[name]
+	if ok {
+		return tenant
+	}
+	tenant = New(binocular.inherit(name))
+	select {
+	case <-binocular.closed:
+		tenant.Close()
+		return tenant
+	default:
+	}
+	binocular.tenants[name] = tenant
+	return tenant
+}
+
+// inherit returns an Option which makes the new instance the tenant with the given name and copies
+// the configuration of the Binocular instance to it, so both are set before the expirer is started.
+func (binocular *Binocular) inherit(name string) Option {
+	return func(tenant *Binocular) {
+		tenant.tenant = name
+		tenant.events = binocular.events
+		tenant.DefaultIndex = binocular.DefaultIndex
+		tenant.metrics = tenantMetrics(binocular.metrics, name)
+		for indexName, options := range binocular.configured {
+			index := tenant.newIndex(options...)
+			index.name, index.metrics = "", nil
+			tenant.indices[indexName] = index
+		}
+		for path, index := range binocular.mappings {
+			tenant.mappings[path] = index
+		}
+		tenant.schema = binocular.schema
+		tenant.strict = binocular.strict
+		tenant.uniqueIDs = binocular.uniqueIDs
+		tenant.workers = binocular.workers
+		tenant.memoryLimit = binocular.memoryLimit
+		tenant.limitPolicy = binocular.limitPolicy
+		tenant.expiryEvery = binocular.expiryEvery
+		tenant.capacity = binocular.capacity
+		tenant.eviction = binocular.eviction
+		tenant.tracer = binocular.tracer
+	}
+}
+
+// DropTenant deletes the tenant with the given name including all of its documents and indices
+// and fires a TenantDropped event. A later call to Tenant with the same name creates an empty tenant.
+// The documents of the tenant are reported as removed to its Metrics.
+// ErrTenantNotFound is returned if the given tenant does not exist.
+func (binocular *Binocular) DropTenant(name string) error {
+	binocular.tenantMut.Lock()
+	tenant, ok := binocular.tenants[name]
+	delete(binocular.tenants, name)
+	binocular.tenantMut.Unlock()
+	if !ok {
+		return ErrTenantNotFound
+	}
+	tenant.Close()
+	tenant.mut.Lock()
+	defer tenant.unlock()
+	if tenant.metrics != nil && len(tenant.docs) > 0 {
+		tenant.metrics.DocumentsRemoved(len(tenant.docs))
+	}
+	tenant.emit(TenantDropped, "", "")
+	return nil
+}
+
+// Tenants returns the names of all tenants in lexical order.
+func (binocular *Binocular) Tenants() []string {
+	binocular.tenantMut.Lock()
+	defer binocular.tenantMut.Unlock()
+	names := make([]string, 0, len(binocular.tenants))
+	for name := range binocular.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TenantStats returns the statistics of the tenant with the given name.
+// ErrTenantNotFound is returned if the given tenant does not exist.
+func (binocular *Binocular) TenantStats(name string) (Stats, error) {
+	binocular.tenantMut.Lock()
+	tenant, ok := binocular.tenants[name]
+	binocular.tenantMut.Unlock()
+	if !ok {
+		return Stats{}, ErrTenantNotFound
+	}
+	return tenant.Stats(), nil
+}
@@ -0,0 +1,168 @@
+package binocular
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBinocular_Tenant(t *testing.T) {
+	b := New(WithIndex("title", WithStemming()))
+	acme := b.Tenant("acme")
+	globex := b.Tenant("globex")
+	if b.Tenant("acme") != acme {
+		t.Error("tenant should be created only once")
+	}
+	if err := acme.AddWithID("1", "rocket skates"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := globex.AddWithID("1", "doomsday device"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := globex.AddWithID("2", "rocket fuel"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := acme.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(result.Refs(), []string{"1"}) {
+		t.Errorf("tenant should only find its own refs: %v", result.Refs())
+	}
+	data, err := acme.Get("1")
+	if err != nil || data != "rocket skates" {
+		t.Errorf("tenant should get its own data: %v", data)
+	}
+	result, err = b.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 0 {
+		t.Errorf("parent should not find refs of tenants: %v", result.Refs())
+	}
+	if _, err := b.Get("1"); err != ErrRefNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	if err := acme.Remove("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := globex.Get("1"); err != nil {
+		t.Error("removing a ref should not affect other tenants")
+	}
+
+	if acme.indices["title"] == nil || acme.indices["title"] == globex.indices["title"] {
+		t.Error("tenants should have their own indices created from the options")
+	}
+}
+
+func TestBinocular_DropTenant(t *testing.T) {
+	b := New()
+	if err := b.Tenant("acme").AddWithID("1", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b.Tenant("globex")
+	if !reflect.DeepEqual(b.Tenants(), []string{"acme", "globex"}) {
+		t.Errorf("wrong tenants: %v", b.Tenants())
+	}
+	stats, err := b.TenantStats("acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stats.Docs != 1 {
+		t.Errorf("wrong stats: %+v", stats)
+	}
+
+	if err := b.DropTenant("acme"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.DropTenant("acme"); err != ErrTenantNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := b.TenantStats("acme"); err != ErrTenantNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := b.Tenant("acme").Get("1"); err != ErrRefNotFound {
+		t.Error("a dropped tenant should be recreated empty")
+	}
+}
+
+func TestBinocular_Tenant_Inherit(t *testing.T) {
+	var evicted []string
+	var events []Event
+	b := New(
+		WithIndex("title", WithStemming()),
+		WithFieldMapping("name", "title"),
+		WithCapacity(1, EvictLRU),
+		WithEvictionCallback(func(id string, data interface{}) {
+			evicted = append(evicted, id)
+		}),
+		WithHook(func(event Event) {
+			events = append(events, event)
+		}),
+	)
+	acme := b.Tenant("acme")
+	if acme.capacity != 1 || acme.mappings["name"] != "title" || !acme.indices["title"].stemming {
+		t.Error("tenant should inherit the configuration")
+	}
+	if acme.onEvict != nil {
+		t.Error("tenant should not inherit the eviction callback")
+	}
+	if err := acme.AddWithID("1", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := acme.AddWithID("2", "anvil"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(evicted) != 0 {
+		t.Errorf("tenant should not call the eviction callback of the parent: %v", evicted)
+	}
+	if len(events) != 3 {
+		t.Errorf("hooks should be called once per event: %v", events)
+	}
+}
+
+func TestBinocular_Tenant_Closed(t *testing.T) {
+	b := New(WithExpiryInterval(time.Millisecond))
+	if err := b.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	acme := b.Tenant("acme")
+	select {
+	case <-acme.closed:
+	default:
+		t.Error("tenant of a closed instance should be closed")
+	}
+	if acme.expirerDone != nil {
+		select {
+		case <-acme.expirerDone:
+		case <-time.After(time.Second):
+			t.Error("expirer of the tenant should be stopped")
+		}
+	}
+	if len(b.Tenants()) != 0 {
+		t.Errorf("tenant of a closed instance should not be registered: %v", b.Tenants())
+	}
+}
+
+func TestBinocular_Tenant_Expiry(t *testing.T) {
+	removed := make(chan Event, 1)
+	b := New(WithExpiryInterval(time.Millisecond), WithHook(func(event Event) {
+		if event.Type == DocumentRemoved {
+			removed <- event
+		}
+	}))
+	defer b.Close()
+	if err := b.Tenant("acme").AddWithTTL("1", "rocket", time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	select {
+	case event := <-removed:
+		if event.Tenant != "acme" || event.Ref != "1" {
+			t.Errorf("wrong event: %v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expirer of the tenant should remove the document")
+	}
+}
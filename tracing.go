@@ -0,0 +1,74 @@
+package binocular
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the name of the instrumentation library passed to the TracerProvider.
+const tracerName = "github.com/mycreepy/go-binocular"
+
+// Span attributes set by a Binocular instance.
+const (
+	attrIndex       = attribute.Key("binocular.index")
+	attrAnalyzer    = attribute.Key("binocular.analyzer")
+	attrQueryLength = attribute.Key("binocular.query.length")
+	attrDistance    = attribute.Key("binocular.distance")
+	attrFilters     = attribute.Key("binocular.filters")
+	attrHits        = attribute.Key("binocular.hits")
+	attrRef         = attribute.Key("binocular.ref")
+	attrTenant      = attribute.Key("binocular.tenant")
+)
+
+// WithTracerProvider creates OpenTelemetry spans for SearchContext, FuzzySearchContext, QueryContext,
+// AddWithIDContext, RemoveContext and CollectContext with the TracerProvider. The spans are children of the span of the context passed to the context-aware methods.
+// Without it no spans are created.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(binocular *Binocular) {
+		binocular.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// startSpan starts a span with the given name and attributes as a child of the span of the context.
+func (binocular *Binocular) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if binocular.tenant != "" {
+		attrs = append(attrs, attrTenant.String(binocular.tenant))
+	}
+	return binocular.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records the error if it is not nil and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// analyzer describes how the Index analyzes text for span attributes.
+func (index *Index) analyzer() string {
+	switch {
+	case index.keyword:
+		return "keyword"
+	case index.stemming:
+		return "stemming"
+	default:
+		return "standard"
+	}
+}
+
+// returns the number of characters of all words of the Query.
+func queryLength(query Query) int {
+	n := 0
+	for _, words := range [][]string{query.All, query.Any, query.None} {
+		for _, word := range words {
+			n += utf8.RuneCountInString(word)
+		}
+	}
+	return n
+}
@@ -0,0 +1,172 @@
+package binocular
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// returns the value of the attribute with the given key of the span.
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestWithTracerProvider(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	b := New(WithIndex("title", WithStemming()), WithTracerProvider(provider))
+	type doc struct {
+		Title string `binocular:"title"`
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	if err := b.AddWithIDContext(ctx, "1", doc{"rocket launches"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := b.SearchContext(ctx, "launch", "title")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := result.CollectContext(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.FuzzySearchContext(ctx, "rockt", "title", 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.SearchContext(ctx, "rocket", "unknown"); err != ErrIndexNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if err := b.RemoveContext(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	tests := []struct {
+		name  string
+		attrs map[attribute.Key]attribute.Value
+		err   bool
+	}{
+		{"binocular.AddWithID", map[attribute.Key]attribute.Value{attrRef: attribute.StringValue("1")}, false},
+		{"binocular.Search", map[attribute.Key]attribute.Value{
+			attrIndex:       attribute.StringValue("title"),
+			attrAnalyzer:    attribute.StringValue("stemming"),
+			attrQueryLength: attribute.IntValue(6),
+			attrDistance:    attribute.IntValue(0),
+			attrFilters:     attribute.IntValue(0),
+			attrHits:        attribute.IntValue(1),
+		}, false},
+		{"binocular.Collect", map[attribute.Key]attribute.Value{attrHits: attribute.IntValue(1)}, false},
+		{"binocular.FuzzySearch", map[attribute.Key]attribute.Value{
+			attrDistance: attribute.IntValue(1),
+			attrHits:     attribute.IntValue(1),
+		}, false},
+		{"binocular.Search", map[attribute.Key]attribute.Value{attrIndex: attribute.StringValue("unknown")}, true},
+		{"binocular.Remove", map[attribute.Key]attribute.Value{attrRef: attribute.StringValue("1")}, false},
+		{"request", nil, false},
+	}
+	if len(spans) != len(tests) {
+		t.Fatalf("expected %d spans, got %d", len(tests), len(spans))
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name != tt.name {
+			t.Errorf("expected span %s, got %s", tt.name, span.Name)
+			continue
+		}
+		if tt.name != "request" && span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %s should be a child of the request span", span.Name)
+		}
+		for key, expected := range tt.attrs {
+			if value, ok := spanAttribute(span, key); !ok || value != expected {
+				t.Errorf("span %s: expected %s to be %v, got %v", span.Name, key, expected.Emit(), value.Emit())
+			}
+		}
+		if (span.Status.Code == codes.Error) != tt.err {
+			t.Errorf("span %s: wrong status %v", span.Name, span.Status)
+		}
+	}
+}
+
+func TestWithTracerProvider_Cancelled(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	b := New(WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.AddWithIDContext(ctx, "1", "rocket"); err != context.Canceled {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, ok := b.docs["1"]; ok {
+		t.Error("document should not have been added")
+	}
+	if err := b.RemoveContext(ctx, "1"); err != context.Canceled {
+		t.Errorf("wrong error: %v", err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	for _, span := range spans {
+		if span.Status.Code != codes.Error || len(span.Events) != 1 {
+			t.Errorf("span %s should record the error", span.Name)
+		}
+	}
+}
+
+func TestBinocular_Search_WithoutTracerProvider(t *testing.T) {
+	b := New()
+	if err := b.AddWithIDContext(context.Background(), "1", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := b.SearchContext(context.Background(), "rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	data, err := result.CollectContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(data) != 1 || data[0] != "rocket" {
+		t.Errorf("wrong data: %v", data)
+	}
+}
+
+func TestWithTracerProvider_Untraced(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	b := New(WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+	if err := b.AddWithID("1", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := b.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := result.Collect(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.FuzzySearch("rockt", DefaultIndex, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := b.Query(Query{All: []string{"rocket"}}, DefaultIndex); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("methods without context should not create spans, got %d", len(spans))
+	}
+}
+
+func TestQueryLength(t *testing.T) {
+	query := Query{All: []string{"größe"}, Any: []string{"rocket"}, None: []string{"日本"}}
+	if n := queryLength(query); n != 13 {
+		t.Errorf("expected 13 characters, got %d", n)
+	}
+}
@@ -0,0 +1,128 @@
+package binocular
+
+import (
+	"container/heap"
+	"time"
+)
+
+// WithExpiryInterval starts a background expirer which removes expired documents in the given interval.
+// Without it expired documents are only removed by Sweep. Close stops the expirer.
+// Expired documents are neither found by searches nor returned by Get before they have been removed,
+// but still count towards Stats, WithCapacity and WithMemoryLimit.
+func WithExpiryInterval(interval time.Duration) Option {
+	return func(binocular *Binocular) {
+		binocular.expiryEvery = interval
+	}
+}
+
+// AddWithTTL will add the data with the given id which expires after the ttl.
+// The ttl overrides the expiry of a struct field tagged with `binocular:",expires"`.
+// It behaves like AddWithID otherwise.
+func (binocular *Binocular) AddWithTTL(id string, data interface{}, ttl time.Duration) error {
+	return binocular.put(id, data, nil, putAdd, ttl)
+}
+
+// Sweep removes all expired documents from the internal data map and every Index they have been added to.
+// It returns the number of removed documents.
+func (binocular *Binocular) Sweep() int {
+	return binocular.sweep(time.Now())
+}
+
+// removes all documents which expired before or at the given time.
+func (binocular *Binocular) sweep(now time.Time) int {
+	binocular.mut.Lock()
+	defer binocular.unlock()
+	removed := 0
+	for len(binocular.expiries) > 0 && !binocular.expiries[0].at.After(now) {
+		e := heap.Pop(&binocular.expiries).(expiry)
+		// skip documents which have been removed or replaced since
+		doc, ok := binocular.docs[e.id]
+		if !ok || !doc.expires.Equal(e.at) {
+			continue
+		}
+		binocular.remove(e.id)
+		removed++
+	}
+	return removed
+}
+
+// Close stops the background expirer of the Binocular instance and its tenants.
+// It is safe to call Close multiple times, the data is kept and can still be used.
+func (binocular *Binocular) Close() error {
+	binocular.closeOnce.Do(func() {
+		close(binocular.closed)
+		if binocular.expirerDone != nil {
+			<-binocular.expirerDone
+		}
+	})
+	binocular.tenantMut.Lock()
+	defer binocular.tenantMut.Unlock()
+	for _, tenant := range binocular.tenants {
+		tenant.Close()
+	}
+	return nil
+}
+
+// starts the background expirer if an interval has been configured.
+func (binocular *Binocular) startExpirer() {
+	if binocular.expiryEvery <= 0 {
+		return
+	}
+	binocular.expirerDone = make(chan struct{})
+	go func() {
+		defer close(binocular.expirerDone)
+		ticker := time.NewTicker(binocular.expiryEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-binocular.closed:
+				return
+			case now := <-ticker.C:
+				binocular.sweep(now)
+			}
+		}
+	}()
+}
+
+// unexpired removes the hits of expired documents which have not been swept yet. The caller must hold the read lock.
+func (binocular *Binocular) unexpired(hits []Hit) []Hit {
+	if len(binocular.expiries) == 0 {
+		return hits
+	}
+	now := time.Now()
+	n := 0
+	for _, hit := range hits {
+		if doc, ok := binocular.docs[hit.Ref]; ok && doc.expired(now) {
+			continue
+		}
+		hits[n] = hit
+		n++
+	}
+	return hits[:n]
+}
+
+// expiry is the time after which the document with the id expires.
+type expiry struct {
+	id string
+	at time.Time
+}
+
+// expiryQueue is a min-heap of expiries ordered by time.
+// Entries of removed or replaced documents are skipped when they are popped.
+type expiryQueue []expiry
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x interface{}) {
+	*q = append(*q, x.(expiry))
+}
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	*q = old[:n-1]
+	return e
+}
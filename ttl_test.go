@@ -0,0 +1,133 @@
+package binocular
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+type ttlTestSession struct {
+	User    string    `binocular:"default"`
+	Expires time.Time `binocular:",expires"`
+}
+
+func TestBinocular_AddWithTTL(t *testing.T) {
+	b := New()
+	if err := b.AddWithTTL("1", "rocket", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.AddWithID("2", "rocket"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := b.Sweep(); n != 0 {
+		t.Errorf("no document should have expired yet, removed %d", n)
+	}
+	if n := b.sweep(time.Now().Add(time.Minute)); n != 1 {
+		t.Errorf("expected 1 expired document, removed %d", n)
+	}
+	if _, err := b.Get("1"); err != ErrRefNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	result, err := b.Search("rocket", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 1 || result.Refs()[0] != "2" {
+		t.Errorf("expired document should be removed from indices: %v", result.Refs())
+	}
+}
+
+func TestBinocular_Sweep_ExpiresField(t *testing.T) {
+	b := New()
+	now := time.Now()
+	if err := b.AddWithID("1", ttlTestSession{"brian", now.Add(time.Minute)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.AddWithID("2", ttlTestSession{"reg", now.Add(time.Minute)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// extending the expiry replaces the old one
+	if err := b.Update("2", ttlTestSession{"reg", now.Add(time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := b.sweep(now.Add(time.Minute)); n != 1 {
+		t.Errorf("expected 1 expired document, removed %d", n)
+	}
+	if _, err := b.Get("2"); err != nil {
+		t.Errorf("updated document should not expire: %v", err)
+	}
+	if n := b.sweep(now.Add(time.Hour)); n != 1 {
+		t.Errorf("expected 1 expired document, removed %d", n)
+	}
+	if len(b.docs) != 0 || len(b.expiries) != 0 {
+		t.Error("all documents and expiries should be removed")
+	}
+}
+
+func TestBinocular_Search_Expired(t *testing.T) {
+	b := New()
+	now := time.Now()
+	if err := b.AddWithID("1", ttlTestSession{"brian", now.Add(-time.Minute)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.AddWithID("2", ttlTestSession{"brian", now.Add(time.Minute)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := b.Search("brian", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 1 || result.Refs()[0] != "2" {
+		t.Errorf("expired document should not be found before it is swept: %v", result.Refs())
+	}
+	if _, err := b.Get("1"); err != ErrRefNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+	if n := b.Sweep(); n != 1 {
+		t.Errorf("expected 1 expired document, removed %d", n)
+	}
+}
+
+func TestWithExpiryInterval(t *testing.T) {
+	b := New(WithExpiryInterval(time.Millisecond))
+	defer b.Close()
+	sub := b.Subscribe(1, BlockOnFull)
+	defer sub.Close()
+	if err := b.AddWithTTL("1", "rocket", time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	<-sub.C
+	select {
+	case event := <-sub.C:
+		if event.Type != DocumentRemoved || event.Ref != "1" {
+			t.Errorf("wrong event: %v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("document should be removed by the expirer")
+	}
+	if err := b.Close(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Errorf("closing twice should be possible: %s", err)
+	}
+}
+
+func TestBinocular_Restore_Expiry(t *testing.T) {
+	b := New()
+	if err := b.AddWithTTL("1", "rocket", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := b.SnapshotContext(context.Background(), &buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	restored := New()
+	if err := restored.RestoreContext(context.Background(), &buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := restored.sweep(time.Now().Add(time.Minute)); n != 1 {
+		t.Errorf("expiry should be restored, removed %d", n)
+	}
+}
@@ -0,0 +1,128 @@
+package binocular
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/google/uuid"
+)
+
+// ErrWrongType indicates that the data of a reference does not have the type of the Typed instance.
+var ErrWrongType = errors.New("wrong type")
+
+// Typed is a type-safe Binocular which only holds data of type T.
+// The `binocular` tags of T are parsed once when creating the Typed instance.
+// All methods of Binocular which do not depend on the type of the data are promoted.
+type Typed[T any] struct {
+	*Binocular
+	plan *structPlan
+}
+
+// NewTyped will create a new Typed instance for data of type T with the given Options.
+func NewTyped[T any](options ...Option) *Typed[T] {
+	typed := &Typed[T]{
+		Binocular: New(options...),
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		typed.plan = cachedStructPlan(t)
+	}
+	return typed
+}
+
+// Add will create a new id for your data and adds it to the Typed instance.
+func (typed *Typed[T]) Add(data T) (string, error) {
+	id := uuid.New().String()
+	return id, typed.AddWithID(id, data)
+}
+
+// AddWithID adds the data with the given id to the Typed instance.
+func (typed *Typed[T]) AddWithID(id string, data T) error {
+	return typed.Binocular.put(id, data, typed.plan, putAdd, 0)
+}
+
+// Update replaces the data of the given id and only re-indexes the indices whose values have changed.
+// ErrRefNotFound is returned if the given id does not exist.
+func (typed *Typed[T]) Update(id string, data T) error {
+	return typed.Binocular.put(id, data, typed.plan, putUpdate, 0)
+}
+
+// Upsert updates the data of the given id if it exists or adds it otherwise.
+func (typed *Typed[T]) Upsert(id string, data T) error {
+	return typed.Binocular.put(id, data, typed.plan, putUpsert, 0)
+}
+
+// Get will retrieve the data at the given id.
+// ErrRefNotFound is returned if the data does not exist.
+// ErrWrongType is returned if the data was added as another type through the embedded Binocular.
+func (typed *Typed[T]) Get(id string) (T, error) {
+	var zero T
+	data, err := typed.Binocular.Get(id)
+	if err != nil {
+		return zero, err
+	}
+	v, ok := data.(T)
+	if !ok {
+		return zero, ErrWrongType
+	}
+	return v, nil
+}
+
+// Search will search the given index with the given word and returns a TypedSearchResult.
+// The result is restricted to the references matching all given Filters.
+// ErrIndexNotFound is returned if the given index or the index of a Filter does not exist.
+func (typed *Typed[T]) Search(word string, index string, filters ...Filter) (*TypedSearchResult[T], error) {
+	result, err := typed.Binocular.Search(word, index, filters...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedSearchResult[T]{result: result}, nil
+}
+
+// FuzzySearch will use the distance to search the given index with the given word and returns a TypedSearchResult.
+// The result is restricted to the references matching all given Filters.
+// ErrIndexNotFound is returned if the given index or the index of a Filter does not exist.
+func (typed *Typed[T]) FuzzySearch(word string, index string, distance int, filters ...Filter) (*TypedSearchResult[T], error) {
+	result, err := typed.Binocular.FuzzySearch(word, index, distance, filters...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedSearchResult[T]{result: result}, nil
+}
+
+// TypedSearchResult holds the resulting references of your search on a Typed instance.
+type TypedSearchResult[T any] struct {
+	result *SearchResult
+}
+
+// Refs returns the list of references found for your search.
+func (searchResult *TypedSearchResult[T]) Refs() []string {
+	return searchResult.result.Refs()
+}
+
+// Hits returns the found references with their relevance score, the most relevant first.
+func (searchResult *TypedSearchResult[T]) Hits() []Hit {
+	return searchResult.result.Hits()
+}
+
+// Collect will use the found references and returns the data associated with it.
+// ErrRefNotFound is returned if a reference does not exist.
+// ErrWrongType is returned if the data of a reference is not of type T.
+func (searchResult *TypedSearchResult[T]) Collect() ([]T, error) {
+	docs, err := searchResult.result.Collect()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]T, len(docs))
+	for i, doc := range docs {
+		v, ok := doc.(T)
+		if !ok {
+			return nil, ErrWrongType
+		}
+		data[i] = v
+	}
+	return data, nil
+}
@@ -0,0 +1,102 @@
+package binocular
+
+import (
+	"errors"
+	"testing"
+)
+
+type typedTestDoc struct {
+	Title  string `binocular:"default"`
+	Author struct {
+		Name string `binocular:"author"`
+	}
+	Year int
+}
+
+func TestNewTyped(t *testing.T) {
+	b := NewTyped[typedTestDoc]()
+	if b.plan == nil {
+		t.Fatal("plan should not be nil")
+	}
+	if len(b.plan.fields) != 2 {
+		t.Errorf("expected 2 planned fields, got %d", len(b.plan.fields))
+	}
+	p := NewTyped[*typedTestDoc]()
+	if p.plan == nil {
+		t.Error("plan should not be nil for pointer types")
+	}
+	s := NewTyped[string]()
+	if s.plan != nil {
+		t.Error("plan should be nil for non struct types")
+	}
+}
+
+func TestTyped_AddAndGet(t *testing.T) {
+	b := NewTyped[typedTestDoc]()
+	testdata := typedTestDoc{Title: "Always look on the bright side of life", Year: 1979}
+	testdata.Author.Name = "Eric Idle"
+	id, err := b.Add(testdata)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b.indices["author"].Postings("idle")[0] != id {
+		t.Error("wrong id")
+	}
+	data, err := b.Get(id)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if data != testdata {
+		t.Error("wrong data")
+	}
+	_, err = b.Get("unknown_id")
+	if err != ErrRefNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestTyped_Get_ErrWrongType(t *testing.T) {
+	b := NewTyped[typedTestDoc]()
+	err := b.Binocular.AddWithID("123", "testdata")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_, err = b.Get("123")
+	if !errors.Is(err, ErrWrongType) {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestTyped_Search(t *testing.T) {
+	b := NewTyped[*typedTestDoc]()
+	testdata := &typedTestDoc{Title: "Houston we have a problem"}
+	err := b.AddWithID("456", testdata)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := b.Search("houston", DefaultIndex)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 1 || result.Refs()[0] != "456" {
+		t.Error("wrong result")
+	}
+	data, err := result.Collect()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if data[0] != testdata {
+		t.Error("wrong data")
+	}
+	result, err = b.FuzzySearch("houstn", DefaultIndex, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Refs()) != 1 {
+		t.Error("wrong result len")
+	}
+	_, err = b.Search("houston", "unknown_idx")
+	if err != ErrIndexNotFound {
+		t.Errorf("wrong error: %v", err)
+	}
+}